@@ -3,13 +3,17 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
 	"github.com/pluralsh/database-interface-controller/pkg/databaserequest"
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
+	"github.com/pluralsh/database-interface-controller/pkg/webhook"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -24,10 +28,21 @@ func init() {
 
 func main() {
 	var enableLeaderElection bool
+	var metricsAddr string
+	var stuckThreshold time.Duration
+	var enableWebhooks bool
+	var webhookPort int
+	var webhookCertDir string
 
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to, e.g. :8080. \"0\" disables metrics.")
+	flag.DurationVar(&stuckThreshold, "metrics-stuck-threshold", 15*time.Minute,
+		"Time an object may stay not ready before it is reported as stuck.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating admission webhooks.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "", "The directory holding tls.crt and tls.key of the webhook server.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -40,7 +55,9 @@ func main() {
 		Scheme:             scheme,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "1237ec41.plural.sh",
-		MetricsBindAddress: "0",
+		MetricsBindAddress: metricsAddr,
+		Port:               webhookPort,
+		CertDir:            webhookCertDir,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to create manager")
@@ -48,13 +65,30 @@ func main() {
 	}
 
 	if err = (&databaserequest.Reconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("DatabaseRequest"),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("DatabaseRequest"),
+		Recorder: mgr.GetEventRecorderFor("database-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DatabaseRequest")
 		os.Exit(1)
 	}
 
+	if enableWebhooks {
+		if err = webhook.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhooks")
+			os.Exit(1)
+		}
+	}
+
+	if err := ctrlmetrics.Registry.Register(&metrics.NotReadyCollector{
+		Reader:    mgr.GetClient(),
+		Kinds:     []string{metrics.KindDatabaseRequest},
+		Threshold: stuckThreshold,
+	}); err != nil {
+		setupLog.Error(err, "unable to register metrics collector")
+		os.Exit(1)
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
@@ -17,6 +17,11 @@ const provisionerName = "fake.database.plural.sh"
 
 var (
 	driverAddress = "unix:///var/lib/database/database.sock"
+
+	tlsCertFile       = ""
+	tlsKeyFile        = ""
+	tlsCAFile         = ""
+	tlsAllowedClients = []string{}
 )
 
 var cmd = &cobra.Command{
@@ -47,7 +52,26 @@ func init() {
 		"driver-addr",
 		"d",
 		driverAddress,
-		"path to unix domain socket where driver should listen")
+		"unix domain socket (unix://) or tcp address (tcp://) where driver should listen")
+	stringFlag(&tlsCertFile,
+		"tls-cert-file",
+		"",
+		tlsCertFile,
+		"server certificate used for mutual TLS on a tcp:// address")
+	stringFlag(&tlsKeyFile,
+		"tls-key-file",
+		"",
+		tlsKeyFile,
+		"server private key used for mutual TLS on a tcp:// address")
+	stringFlag(&tlsCAFile,
+		"tls-ca-file",
+		"",
+		tlsCAFile,
+		"CA bundle used to verify client certificates")
+	persistentFlags.StringSliceVar(&tlsAllowedClients,
+		"tls-allowed-clients",
+		tlsAllowedClients,
+		"identities (common name or SAN) allowed to connect, any client signed by the CA is accepted when empty")
 	viper.BindPFlags(cmd.PersistentFlags())
 	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
 		if viper.IsSet(f.Name) && viper.GetString(f.Name) != "" {
@@ -58,7 +82,17 @@ func init() {
 
 func run(ctx context.Context, args []string) error {
 	identityServer, bucketProvisioner := NewDriver(provisionerName)
-	server, err := provisioner.NewDefaultProvisionerServer(driverAddress,
+	var tlsConfig *provisioner.TLSConfig
+	if tlsCertFile != "" || tlsKeyFile != "" || tlsCAFile != "" {
+		tlsConfig = &provisioner.TLSConfig{
+			CertFile:          tlsCertFile,
+			KeyFile:           tlsKeyFile,
+			CAFile:            tlsCAFile,
+			AllowedIdentities: tlsAllowedClients,
+		}
+	}
+	server, err := provisioner.NewProvisionerServerWithTLS(driverAddress,
+		tlsConfig,
 		identityServer,
 		bucketProvisioner)
 	if err != nil {
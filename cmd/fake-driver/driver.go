@@ -54,9 +54,9 @@ func (ps *ProvisionerServer) DriverDeleteDatabase(_ context.Context, req *databa
 
 // This call grants access to an account. The account_name in the request shall be used as a unique identifier to create credentials.
 // The account_id returned in the response will be used as the unique identifier for deleting this access when calling DriverRevokeDatabaseAccess.
-func (ps *ProvisionerServer) DriverGrantDatabaseAccess(context.Context, *databasespec.DriverGrantDatabaseAccessRequest) (*databasespec.DriverGrantDatabaseAccessResponse, error) {
+func (ps *ProvisionerServer) DriverGrantDatabaseAccess(_ context.Context, req *databasespec.DriverGrantDatabaseAccessRequest) (*databasespec.DriverGrantDatabaseAccessResponse, error) {
 	resp := &databasespec.DriverGrantDatabaseAccessResponse{
-		AccountId:   "abc",
+		AccountId:   req.GetName(),
 		Credentials: map[string]*databasespec.CredentialDetails{},
 	}
 	resp.Credentials["cred"] = &databasespec.CredentialDetails{Secrets: map[string]string{"a": "b"}}
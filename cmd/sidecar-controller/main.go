@@ -4,17 +4,20 @@ import (
 	"context"
 	"flag"
 	"os"
+	"time"
 
 	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
 	databasespec "github.com/pluralsh/database-interface-api/spec"
 	"github.com/pluralsh/database-interface-controller/pkg/database"
 	databaseaccess "github.com/pluralsh/database-interface-controller/pkg/database-access"
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
 	"github.com/pluralsh/database-interface-controller/pkg/provisioner"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -30,15 +33,34 @@ func init() {
 
 func main() {
 	var enableLeaderElection bool
+	var metricsAddr string
+	var stuckThreshold time.Duration
+	var provisioningTimeout time.Duration
+	var resyncPeriod time.Duration
 	var debug bool
 	var driverAddress string
+	var driverTLS provisioner.TLSConfig
+	var vaultConfig databaseaccess.VaultConfig
 
 	flag.BoolVar(&debug, "debug", true,
 		"Enable debug")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	flag.StringVar(&driverAddress, "driver-addr", "unix:///var/lib/database/database.sock", "path to unix domain socket where driver is listening")
+	flag.StringVar(&driverAddress, "driver-addr", "unix:///var/lib/database/database.sock", "unix domain socket (unix://) or tcp address (tcp://) where driver is listening")
+	flag.StringVar(&driverTLS.CertFile, "driver-tls-cert-file", "", "client certificate used for mutual TLS with a tcp:// driver")
+	flag.StringVar(&driverTLS.KeyFile, "driver-tls-key-file", "", "client private key used for mutual TLS with a tcp:// driver")
+	flag.StringVar(&driverTLS.CAFile, "driver-tls-ca-file", "", "CA bundle used to verify the driver certificate")
+	flag.StringVar(&driverTLS.ServerName, "driver-tls-server-name", "", "name expected in the driver certificate, defaults to the host of driver-addr")
+	flag.StringVar(&vaultConfig.Address, "vault-address", "", "address of the Vault server used by DatabaseAccessClasses with the vault credential sink, e.g. https://vault.example:8200")
+	flag.StringVar(&vaultConfig.TokenFile, "vault-token-file", "", "file holding the Vault token, read on every request")
+	flag.DurationVar(&provisioningTimeout, "provisioning-timeout", 30*time.Minute,
+		"Time the driver may take to provision a database before the Database is marked as failed. 0 waits forever.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Hour,
+		"Resync period of the informer cache. Every resync reconciles all cached Databases and DatabaseAccesses again, the driver is not queried.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to, e.g. :8080. \"0\" disables metrics.")
+	flag.DurationVar(&stuckThreshold, "metrics-stuck-threshold", 15*time.Minute,
+		"Time an object may stay not ready before it is reported as stuck.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -47,7 +69,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 	ctxInfo := context.Background()
-	provisionerClient, err := provisioner.NewDefaultProvisionerClient(ctxInfo, driverAddress, debug)
+	var tlsConfig *provisioner.TLSConfig
+	if driverTLS.CertFile != "" || driverTLS.KeyFile != "" || driverTLS.CAFile != "" {
+		tlsConfig = &driverTLS
+	}
+	provisionerClient, err := provisioner.NewProvisionerClientWithTLS(ctxInfo, driverAddress, tlsConfig, debug)
 	if err != nil {
 		setupLog.Error(err, "unable to create provisioner client")
 		os.Exit(1)
@@ -63,7 +89,8 @@ func main() {
 		Scheme:             scheme,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "1237ec41.plural.sh",
-		MetricsBindAddress: "0",
+		MetricsBindAddress: metricsAddr,
+		SyncPeriod:         &resyncPeriod,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to create manager")
@@ -71,10 +98,12 @@ func main() {
 	}
 
 	if err = (&database.Reconciler{
-		Client:            mgr.GetClient(),
-		Log:               ctrl.Log.WithName("controllers").WithName("Database"),
-		DriverName:        info.Name,
-		ProvisionerClient: provisionerClient,
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("Database"),
+		Recorder:            mgr.GetEventRecorderFor("database-provisioner"),
+		DriverName:          info.Name,
+		ProvisionerClient:   provisionerClient,
+		ProvisioningTimeout: provisioningTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Database")
 		os.Exit(1)
@@ -82,13 +111,25 @@ func main() {
 	if err = (&databaseaccess.Reconciler{
 		Client:            mgr.GetClient(),
 		Log:               ctrl.Log.WithName("controllers").WithName("DatabaseAccess"),
+		Recorder:          mgr.GetEventRecorderFor("database-provisioner"),
 		DriverName:        info.Name,
 		ProvisionerClient: provisionerClient,
+		Vault:             vaultConfig,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DatabaseAccess")
 		os.Exit(1)
 	}
 
+	if err := ctrlmetrics.Registry.Register(&metrics.NotReadyCollector{
+		Reader:     mgr.GetClient(),
+		Kinds:      []string{metrics.KindDatabase, metrics.KindDatabaseAccess},
+		DriverName: info.Name,
+		Threshold:  stuckThreshold,
+	}); err != nil {
+		setupLog.Error(err, "unable to register metrics collector")
+		os.Exit(1)
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
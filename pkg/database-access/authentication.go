@@ -0,0 +1,156 @@
+package databaseaccess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	databasespec "github.com/pluralsh/database-interface-api/spec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SecretKeyAuthenticationType and SecretKeyAccountID are written to the credentials Secret of
+	// IAM accesses, next to the identity binding returned by the driver.
+	SecretKeyAuthenticationType = "authenticationType"
+	SecretKeyAccountID          = "accountId"
+)
+
+// serviceAccountSecretKeys maps the ServiceAccount identity parameters to the keys written to the credentials Secret.
+var serviceAccountSecretKeys = map[string]string{
+	ServiceAccountNamespaceKey:       "serviceAccountNamespace",
+	ServiceAccountNameKey:            "serviceAccountName",
+	ServiceAccountAudienceAnnotation: "serviceAccountAudience",
+}
+
+// authenticationTypes maps the authenticationType of a DatabaseAccessClass onto the driver API.
+// login and key grant an account with static credentials, iam binds the account to an identity.
+var authenticationTypes = map[string]databasespec.AuthenticationType{
+	"login": databasespec.AuthenticationType_Key,
+	"key":   databasespec.AuthenticationType_Key,
+	"iam":   databasespec.AuthenticationType_IAM,
+}
+
+// SupportedAuthenticationTypes lists the accepted values of the DatabaseAccessClass authenticationType.
+var SupportedAuthenticationTypes = []string{"login", "key", "iam"}
+
+// identityKeysIAM are the only keys of the driver credentials written for IAM accesses, which must not rely on a static secret.
+// They locate the database and name the identity the account is bound to, any other key may hold a secret and is dropped.
+var identityKeysIAM = []string{"host", "port", "endpoint", "region", "database", "user", "username", "role", "roleArn", "principal"}
+
+// ParseAuthenticationType returns the driver authentication type of the DatabaseAccessClass. An empty value defaults to login.
+func ParseAuthenticationType(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (databasespec.AuthenticationType, error) {
+	value := strings.ToLower(string(databaseAccessClass.AuthenticationType))
+	if value == "" {
+		return databasespec.AuthenticationType_Key, nil
+	}
+	authenticationType, ok := authenticationTypes[value]
+	if !ok {
+		return databasespec.AuthenticationType_UnknownAuthenticationType, fmt.Errorf("unsupported authenticationType %q, must be one of %s",
+			databaseAccessClass.AuthenticationType, strings.Join(SupportedAuthenticationTypes, ", "))
+	}
+	return authenticationType, nil
+}
+
+// accessAuthenticationType returns the authentication type a DatabaseAccess is granted with.
+// Accesses bound to a ServiceAccount always use IAM.
+func accessAuthenticationType(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (databasespec.AuthenticationType, error) {
+	authenticationType, err := ParseAuthenticationType(databaseAccessClass)
+	if err != nil {
+		return authenticationType, err
+	}
+	if databaseAccess.Annotations[ServiceAccountAnnotation] != "" {
+		return databasespec.AuthenticationType_IAM, nil
+	}
+	return authenticationType, nil
+}
+
+// grantParameters returns the parameters of the DatabaseAccessClass extended with the ServiceAccount identity
+// and the privileges of the DatabaseAccess.
+func grantParameters(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (map[string]string, error) {
+	privileges, err := getPrivileges(databaseAccessClass, databaseAccess)
+	if err != nil {
+		return nil, err
+	}
+	identity := serviceAccountIdentity(databaseAccessClass, databaseAccess)
+	if identity == nil && privileges.String() == "" {
+		return databaseAccessClass.Parameters, nil
+	}
+
+	parameters := make(map[string]string, len(databaseAccessClass.Parameters)+len(identity)+2)
+	for key, value := range databaseAccessClass.Parameters {
+		parameters[key] = value
+	}
+	for key, value := range identity {
+		parameters[key] = value
+	}
+	for key, value := range privileges.parameters() {
+		parameters[key] = value
+	}
+	return parameters, nil
+}
+
+// authenticationError explains driver errors caused by an authentication type the driver does not implement.
+// The driver API has no way to announce the supported types up front, so this is only known once access is granted.
+func authenticationError(err error, databaseAccessClass *databasev1alpha1.DatabaseAccessClass, authenticationType databasespec.AuthenticationType) error {
+	switch status.Code(err) {
+	case codes.Unimplemented, codes.InvalidArgument:
+		return fmt.Errorf("driver %s does not support authenticationType %s (%s) of DatabaseAccessClass %s: %w",
+			databaseAccessClass.DriverName, databaseAccessClass.AuthenticationType, authenticationType, databaseAccessClass.Name, err)
+	default:
+		return err
+	}
+}
+
+// grantFailedReason returns the event reason for a failed grant.
+func grantFailedReason(err error) string {
+	// The driver error may be wrapped by authenticationError, status.Code does not unwrap it.
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return ReasonAccessGrantFailed
+	}
+	switch grpcErr.GRPCStatus().Code() {
+	case codes.Unimplemented, codes.InvalidArgument:
+		return ReasonAuthenticationNotSupported
+	default:
+		return ReasonAccessGrantFailed
+	}
+}
+
+// credentialsData returns the Secret data for the credentials returned by the driver. IAM accesses get the
+// identity binding returned by the driver, without static secrets, and the account the identity is bound to.
+func credentialsData(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess, rsp *databasespec.DriverGrantDatabaseAccessResponse) map[string]string {
+	data := map[string]string{}
+	if cred, ok := rsp.Credentials["cred"]; ok && cred != nil {
+		for key, value := range cred.Secrets {
+			data[key] = value
+		}
+	}
+
+	if authenticationType, _ := accessAuthenticationType(databaseAccessClass, databaseAccess); authenticationType != databasespec.AuthenticationType_IAM {
+		return data
+	}
+	for key := range data {
+		if !containsFold(identityKeysIAM, key) {
+			delete(data, key)
+		}
+	}
+	data[SecretKeyAuthenticationType] = "iam"
+	data[SecretKeyAccountID] = rsp.AccountId
+	for key, value := range serviceAccountIdentity(databaseAccessClass, databaseAccess) {
+		data[serviceAccountSecretKeys[key]] = value
+	}
+
+	return data
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
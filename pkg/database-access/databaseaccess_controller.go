@@ -5,28 +5,37 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
 	databasespec "github.com/pluralsh/database-interface-api/spec"
 	databasectrl "github.com/pluralsh/database-interface-controller/pkg/database"
 	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // Reconciler reconciles a DatabaseAccess object
 type Reconciler struct {
 	client.Client
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
 
 	DriverName        string
 	ProvisionerClient databasespec.ProvisionerClient
+	// Vault configures the Vault credential sink used by DatabaseAccessClasses with the vault sink.
+	Vault VaultConfig
 }
 
 const (
@@ -34,6 +43,26 @@ const (
 	DatabaseAccessFinalizer = "pluralsh.database-interface-controller/databaseaccess-protection"
 )
 
+const (
+	// Event reasons recorded by the DatabaseAccess reconciler.
+	ReasonAccessGranted              = "AccessGranted"
+	ReasonAccessGrantFailed          = "AccessGrantFailed"
+	ReasonAccessRevoked              = "AccessRevoked"
+	ReasonAccessRevokeFailed         = "AccessRevokeFailed"
+	ReasonSecretCreated              = "SecretCreated"
+	ReasonSecretUpdated              = "SecretUpdated"
+	ReasonSecretDeleted              = "SecretDeleted"
+	ReasonCredentialSinkFailed       = "CredentialSinkFailed"
+	ReasonSecretTemplateFailed       = "SecretTemplateFailed"
+	ReasonCredentialsRotated         = "CredentialsRotated"
+	ReasonFinalizerRemoved           = "FinalizerRemoved"
+	ReasonAccessClassNotFound        = "DatabaseAccessClassNotFound"
+	ReasonAuthenticationNotSupported = "AuthenticationNotSupported"
+	ReasonServiceAccountNotFound     = "ServiceAccountNotFound"
+	ReasonPrivilegesNotAllowed       = "PrivilegesNotAllowed"
+	ReasonAccessExpired              = "AccessExpired"
+)
+
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("DatabaseAccess", req.NamespacedName)
 
@@ -52,22 +81,48 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, nil
 	}
 
-	if databaseAccess.Status.AccessGranted && databaseAccess.Status.AccountID != "" {
-		log.Info("DatabaseAccess already exists")
+	// Expired accesses are never granted again.
+	if kubernetes.IsExpired(databaseAccess) {
 		return ctrl.Result{}, nil
 	}
+	expiresAt, expired, err := r.reconcileExpiry(ctx, databaseAccess)
+	if err != nil || expired {
+		return ctrl.Result{}, err
+	}
+
+	if databaseAccess.Status.AccessGranted && databaseAccess.Annotations[ServiceAccountAnnotation] != "" {
+		if revoked, err := r.reconcileServiceAccount(ctx, databaseAccess); err != nil || revoked {
+			return ctrl.Result{}, err
+		}
+	}
+	if databaseAccess.Status.AccessGranted && databaseAccess.Status.AccountID != "" {
+		result, err := r.reconcileRotation(ctx, databaseAccess)
+		if err != nil {
+			return result, err
+		}
+		return requeueBefore(time.Now(), result, expiresAt), nil
+	}
 
 	databaseRequestName := databaseAccess.Spec.DatabaseRequestName
 	databaseAccessClassName := databaseAccess.Spec.DatabaseAccessClassName
 	log.Info("Add DatabaseAccess")
 
-	secretCredName := databaseAccess.Spec.CredentialsSecretName
-	if secretCredName == "" {
+	if databaseAccess.Spec.CredentialsSecretName == "" {
 		return ctrl.Result{}, errors.New("CredentialsSecretName not defined in the DatabaseAccess")
 	}
 
 	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
-	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccessClassName}, databaseAccessClass); err != nil {
+	if databaseAccessClassName == "" {
+		defaultClass, err := r.defaultDatabaseAccessClass(ctx, databaseAccess)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if defaultClass == nil {
+			log.Info("Skipping databaseaccess for driver")
+			return ctrl.Result{}, nil
+		}
+		databaseAccessClass = defaultClass
+	} else if err := r.Get(ctx, client.ObjectKey{Name: databaseAccessClassName}, databaseAccessClass); err != nil {
 		log.Error(err, "Failed to get DatabaseAccessClass")
 		return ctrl.Result{}, err
 	}
@@ -75,6 +130,29 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Info("Skipping databaseaccess for driver")
 		return ctrl.Result{}, nil
 	}
+	if _, err := ParseAuthenticationType(databaseAccessClass); err != nil {
+		log.Error(err, "Invalid authenticationType in DatabaseAccessClass")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonAuthenticationNotSupported, err.Error())
+		return ctrl.Result{}, err
+	}
+	privileges, err := getPrivileges(databaseAccessClass, databaseAccess)
+	if err != nil {
+		log.Error(err, "Invalid privileges")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonPrivilegesNotAllowed, err.Error())
+		return ctrl.Result{}, err
+	}
+	templates, err := secretTemplates(databaseAccessClass)
+	if err != nil {
+		log.Error(err, "Invalid secret templates in DatabaseAccessClass")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonSecretTemplateFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+	sink, err := r.credentialSink(databaseAccessClass)
+	if err != nil {
+		log.Error(err, "Invalid credential sink in DatabaseAccessClass")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, err.Error())
+		return ctrl.Result{}, err
+	}
 
 	namespace := databaseAccess.ObjectMeta.Namespace
 	databaseRequest := &databasev1alpha1.DatabaseRequest{}
@@ -101,49 +179,70 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
-	accountName := fmt.Sprintf("%s-%s", "account", databaseAccess.Name)
-	grantAccessReq := &databasespec.DriverGrantDatabaseAccessRequest{
-		DatabaseId:         database.Status.DatabaseID,
-		Name:               accountName,
-		AuthenticationType: 0,
-		Parameters:         databaseAccessClass.Parameters,
-	}
-
-	rsp, err := r.ProvisionerClient.DriverGrantDatabaseAccess(ctx, grantAccessReq)
-	if err != nil {
-		if status.Code(err) != codes.AlreadyExists {
-			log.Error(err, "Failed to grant access")
+	var serviceAccount *corev1.ServiceAccount
+	if serviceAccountName := databaseAccess.Annotations[ServiceAccountAnnotation]; serviceAccountName != "" {
+		if serviceAccount, err = r.getServiceAccount(ctx, databaseAccess); err != nil {
 			return ctrl.Result{}, err
 		}
-
+		// The ServiceAccount watch triggers the grant once the ServiceAccount is created.
+		if serviceAccount == nil {
+			log.Info("Waiting for ServiceAccount", "ServiceAccount", serviceAccountName)
+			r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonServiceAccountNotFound, "ServiceAccount %s does not exist", serviceAccountName)
+			return ctrl.Result{}, nil
+		}
 	}
-	credentials := rsp.Credentials
 
-	credentialSecret := &corev1.Secret{}
-	if err := r.Get(ctx, client.ObjectKey{Name: secretCredName, Namespace: namespace}, credentialSecret); err != nil {
-		if !apierrors.IsNotFound(err) {
-			log.Error(err, "Failed to get credential secret")
+	// The finalizers are added before the driver is called, so an account is never granted for an access that can go away unnoticed.
+	if err := kubernetes.TryAddFinalizer(ctx, r.Client, database, databasectrl.DatabaseAccessFinalizer); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := kubernetes.TryAddFinalizer(ctx, r.Client, databaseAccess, DatabaseAccessFinalizer); err != nil {
+		return ctrl.Result{}, err
+	}
+	// An account granted by an interrupted attempt is revoked before a new one is granted.
+	if databaseAccess.Annotations[PendingAccountIDAnnotation] != "" {
+		if err := r.resumeRotation(ctx, databaseAccess, privileges); err != nil {
 			return ctrl.Result{}, err
 		}
+	}
 
-		if err := r.Create(ctx, &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:       secretCredName,
-				Namespace:  namespace,
-				Finalizers: []string{SecretFinalizer},
-			},
-			StringData: credentials["cred"].Secrets,
-			Type:       corev1.SecretTypeOpaque,
-		}); err != nil {
-			log.Error(err, "Failed to create secret")
-			return ctrl.Result{}, err
+	accountName := fmt.Sprintf("%s-%s", "account", databaseAccess.Name)
+	rsp, err := r.grantAccess(ctx, database, databaseAccessClass, databaseAccess, accountName)
+	if err != nil {
+		log.Error(err, "Failed to grant access")
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, grantFailedReason(err), "Driver failed to grant access: %v", err)
+		return ctrl.Result{}, err
+	}
+	if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{PendingAccountIDAnnotation: rsp.AccountId}); err != nil {
+		log.Error(err, "Failed to record granted account", "AccountID", rsp.AccountId)
+		if revokeErr := r.revokeAccess(ctx, database, rsp.AccountId); revokeErr != nil {
+			log.Error(revokeErr, "Driver failed to revoke unrecorded account", "AccountID", rsp.AccountId)
+			r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonAccessRevokeFailed, "Driver failed to revoke account %s: %v", rsp.AccountId, revokeErr)
 		}
+		return ctrl.Result{}, err
+	}
+	secretData, err := renderSecretData(templates, credentialsData(databaseAccessClass, databaseAccess, rsp))
+	if err != nil {
+		log.Error(err, "Failed to render credential secret")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonSecretTemplateFailed, err.Error())
+		return ctrl.Result{}, err
 	}
 
-	if err := kubernetes.TryAddFinalizer(ctx, r.Client, database, databasectrl.DatabaseAccessFinalizer); err != nil {
+	created, err := r.writeCredentials(ctx, sink, databaseAccess, secretData)
+	if err != nil {
+		log.Error(err, "Failed to write credentials", "location", sink.Location(databaseAccess))
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, "Failed to write credentials to %s: %v", sink.Location(databaseAccess), err)
 		return ctrl.Result{}, err
 	}
-	if err := kubernetes.TryAddFinalizer(ctx, r.Client, databaseAccess, DatabaseAccessFinalizer); err != nil {
+	if created {
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonSecretCreated, "Created credentials %s", sink.Location(databaseAccess))
+	}
+
+	applied := map[string]string{AppliedPrivilegesAnnotation: privileges.String()}
+	if serviceAccount != nil {
+		applied[ServiceAccountUIDAnnotation] = string(serviceAccount.UID)
+	}
+	if err := r.patchAnnotations(ctx, databaseAccess, applied); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -153,34 +252,224 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if err := r.Status().Update(ctx, databaseAccess); err != nil {
 		return ctrl.Result{}, err
 	}
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessGranted, "Granted access to Database %s for account %s", database.Name, rsp.AccountId)
+	if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{PendingAccountIDAnnotation: ""}); err != nil {
+		return ctrl.Result{}, err
+	}
 
-	return ctrl.Result{}, nil
+	return requeueAt(time.Now(), expiresAt), nil
+}
+
+func (r *Reconciler) grantAccess(ctx context.Context, database *databasev1alpha1.Database, databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess, accountName string) (*databasespec.DriverGrantDatabaseAccessResponse, error) {
+	authenticationType, err := accessAuthenticationType(databaseAccessClass, databaseAccess)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := grantParameters(databaseAccessClass, databaseAccess)
+	if err != nil {
+		return nil, err
+	}
+	grantAccessReq := &databasespec.DriverGrantDatabaseAccessRequest{
+		DatabaseId:         database.Status.DatabaseID,
+		Name:               accountName,
+		AuthenticationType: authenticationType,
+		Parameters:         parameters,
+	}
+
+	rsp, err := r.ProvisionerClient.DriverGrantDatabaseAccess(ctx, grantAccessReq)
+	if err != nil {
+		if status.Code(err) != codes.AlreadyExists {
+			metrics.AccessesFailed.WithLabelValues(r.DriverName, databaseAccessClass.Name).Inc()
+			return nil, authenticationError(err, databaseAccessClass, authenticationType)
+		}
+	}
+	if rsp == nil {
+		metrics.AccessesFailed.WithLabelValues(r.DriverName, databaseAccessClass.Name).Inc()
+		return nil, errors.New("DriverGrantDatabaseAccess returned a nil response")
+	}
+	metrics.AccessesGranted.WithLabelValues(r.DriverName, databaseAccessClass.Name).Inc()
+
+	return rsp, nil
+}
+
+func (r *Reconciler) revokeAccess(ctx context.Context, database *databasev1alpha1.Database, accountID string) error {
+	req := &databasespec.DriverRevokeDatabaseAccessRequest{
+		DatabaseId: database.Status.DatabaseID,
+		AccountId:  accountID,
+	}
+	if _, err := r.ProvisionerClient.DriverRevokeDatabaseAccess(ctx, req); err != nil {
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// defaultDatabaseAccessClass sets the default DatabaseAccessClass of the driver on a DatabaseAccess without a class.
+// It returns nil when the referenced Database is served by another driver.
+func (r *Reconciler) defaultDatabaseAccessClass(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (*databasev1alpha1.DatabaseAccessClass, error) {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	database, err := r.getDatabase(ctx, databaseAccess)
+	if err != nil {
+		return nil, err
+	}
+	if database == nil {
+		return nil, errors.New("The default DatabaseAccessClass can't be chosen before the Database is bound")
+	}
+	if !strings.EqualFold(database.Spec.DriverName, r.DriverName) {
+		return nil, nil
+	}
+
+	databaseAccessClass, err := kubernetes.GetDefaultDatabaseAccessClass(ctx, r.Client, r.DriverName)
+	if err != nil {
+		log.Error(err, "Failed to get default DatabaseAccessClass")
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonAccessClassNotFound, "No databaseAccessClassName set and no default DatabaseAccessClass: %v", err)
+		return nil, err
+	}
+
+	databaseAccess.Spec.DatabaseAccessClassName = databaseAccessClass.Name
+	if err := r.Update(ctx, databaseAccess); err != nil {
+		return nil, err
+	}
+	log.Info("Using default DatabaseAccessClass", "DatabaseAccessClass", databaseAccessClass.Name)
+
+	return databaseAccessClass, nil
 }
 
 func (r *Reconciler) deleteDatabaseAccessOp(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) error {
-	credSecretName := databaseAccess.Spec.CredentialsSecretName
-	credentialSecret := &corev1.Secret{}
-	if err := r.Get(ctx, client.ObjectKey{Name: credSecretName, Namespace: databaseAccess.Namespace}, credentialSecret); err != nil {
-		return err
+	if !controllerutil.ContainsFinalizer(databaseAccess, DatabaseAccessFinalizer) {
+		return nil
 	}
-	if err := r.Delete(ctx, credentialSecret); err != nil {
-		return err
+
+	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.DatabaseAccessClassName}, databaseAccessClass); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+	} else if !strings.EqualFold(databaseAccessClass.DriverName, r.DriverName) {
+		return nil
+	}
+
+	if databaseAccess.Status.AccountID != "" || databaseAccess.Annotations[PendingAccountIDAnnotation] != "" || databaseAccess.Annotations[PreviousAccountIDAnnotation] != "" {
+		database, err := r.getDatabase(ctx, databaseAccess)
+		if err != nil {
+			return err
+		}
+		// When the Database is already gone the account went away together with it.
+		if database != nil && database.Status.DatabaseID != "" {
+			if !strings.EqualFold(database.Spec.DriverName, r.DriverName) {
+				return nil
+			}
+			if err := r.revokeAccounts(ctx, databaseAccess, database); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, credentialSecret, SecretFinalizer); err != nil {
+	if err := r.deleteCredentials(ctx, databaseAccessClass, databaseAccess); err != nil {
 		return err
 	}
 
 	if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, databaseAccess, DatabaseAccessFinalizer); err != nil {
 		return err
 	}
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed finalizer %s", DatabaseAccessFinalizer)
+
+	return nil
+}
+
+// revokeAccounts revokes the account of the DatabaseAccess, the previous account still waiting for revocation
+// after a rotation and the account of an interrupted grant or rotation.
+func (r *Reconciler) revokeAccounts(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, database *databasev1alpha1.Database) error {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	var accountIDs []string
+	seen := sets.NewString()
+	for _, accountID := range []string{databaseAccess.Status.AccountID, databaseAccess.Annotations[PreviousAccountIDAnnotation], databaseAccess.Annotations[PendingAccountIDAnnotation]} {
+		if accountID != "" && !seen.Has(accountID) {
+			accountIDs = append(accountIDs, accountID)
+			seen.Insert(accountID)
+		}
+	}
+	for _, accountID := range accountIDs {
+		if err := r.revokeAccess(ctx, database, accountID); err != nil {
+			log.Error(err, "Driver failed to revoke access", "AccountID", accountID)
+			r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonAccessRevokeFailed, "Driver failed to revoke account %s: %v", accountID, err)
+			return err
+		}
+		log.Info("Successfully revoked access", "AccountID", accountID)
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessRevoked, "Revoked account %s", accountID)
+	}
 
 	return nil
 }
 
+// deleteCredentials deletes the credentials of the DatabaseAccess from the sink they were written to.
+func (r *Reconciler) deleteCredentials(ctx context.Context, databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+	sink, err := r.appliedCredentialSink(databaseAccessClass, databaseAccess)
+	if errors.Is(err, errVaultClassGone) {
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, "Credentials left in Vault: %v", err)
+		return nil
+	}
+	if err != nil {
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, err.Error())
+		return err
+	}
+	deleted, err := sink.Delete(ctx, databaseAccess)
+	if err != nil {
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, "Failed to delete credentials %s: %v", sink.Location(databaseAccess), err)
+		return err
+	}
+	if deleted {
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonSecretDeleted, "Deleted credentials %s", sink.Location(databaseAccess))
+	}
+
+	return nil
+}
+
+// getDatabase returns the Database bound to the DatabaseRequest referenced by the DatabaseAccess.
+// It returns nil when the Database does not exist anymore.
+func (r *Reconciler) getDatabase(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (*databasev1alpha1.Database, error) {
+	namespace := databaseAccess.Namespace
+	databaseRequestName := databaseAccess.Spec.DatabaseRequestName
+
+	databaseRequest := &databasev1alpha1.DatabaseRequest{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseRequestName, Namespace: namespace}, databaseRequest); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if databaseRequest.Status.DatabaseName != "" {
+		database := &databasev1alpha1.Database{}
+		if err := r.Get(ctx, client.ObjectKey{Name: databaseRequest.Status.DatabaseName}, database); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return database, nil
+	}
+
+	// The DatabaseRequest may already be gone, fall back to the back-reference on the Database.
+	var databaseList databasev1alpha1.DatabaseList
+	if err := r.List(ctx, &databaseList); err != nil {
+		return nil, err
+	}
+	for i := range databaseList.Items {
+		ref := databaseList.Items[i].Spec.DatabaseRequest
+		if ref != nil && ref.Namespace == namespace && ref.Name == databaseRequestName {
+			return &databaseList.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasev1alpha1.DatabaseAccess{}).
+		Watches(&source.Kind{Type: &corev1.ServiceAccount{}}, handler.EnqueueRequestsFromMapFunc(r.accessesForServiceAccount)).
 		Complete(r)
 }
@@ -0,0 +1,196 @@
+package databaseaccess
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	databasespec "github.com/pluralsh/database-interface-api/spec"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fakeProvisioner grants numbered accounts and records the revoked ones.
+type fakeProvisioner struct {
+	databasespec.ProvisionerClient
+
+	granted []string
+	revoked []string
+}
+
+func (p *fakeProvisioner) DriverGrantDatabaseAccess(_ context.Context, req *databasespec.DriverGrantDatabaseAccessRequest, _ ...grpc.CallOption) (*databasespec.DriverGrantDatabaseAccessResponse, error) {
+	accountID := fmt.Sprintf("%s-%d", req.Name, len(p.granted)+1)
+	p.granted = append(p.granted, accountID)
+	return &databasespec.DriverGrantDatabaseAccessResponse{
+		AccountId:   accountID,
+		Credentials: map[string]*databasespec.CredentialDetails{"cred": {Secrets: map[string]string{"password": "secret"}}},
+	}, nil
+}
+
+func (p *fakeProvisioner) DriverRevokeDatabaseAccess(_ context.Context, req *databasespec.DriverRevokeDatabaseAccessRequest, _ ...grpc.CallOption) (*databasespec.DriverRevokeDatabaseAccessResponse, error) {
+	p.revoked = append(p.revoked, req.AccountId)
+	return &databasespec.DriverRevokeDatabaseAccessResponse{}, nil
+}
+
+func newGrantTestReconciler(t *testing.T, databaseAccess *databasev1alpha1.DatabaseAccess) (*Reconciler, *fakeProvisioner) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := databasev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	objects := []client.Object{
+		&databasev1alpha1.DatabaseAccessClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+			DriverName: "postgres.database.plural.sh",
+		},
+		&databasev1alpha1.DatabaseRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "payments"},
+			Status:     databasev1alpha1.DatabaseRequestStatus{DatabaseName: "postgres-orders", Ready: true},
+		},
+		&databasev1alpha1.Database{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres-orders"},
+			Spec:       databasev1alpha1.DatabaseSpec{DriverName: "postgres.database.plural.sh"},
+			Status:     databasev1alpha1.DatabaseStatus{Ready: true, DatabaseID: "db-1"},
+		},
+		databaseAccess,
+	}
+	provisioner := &fakeProvisioner{}
+	return &Reconciler{
+		Client:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Log:               logr.Discard(),
+		Recorder:          record.NewFakeRecorder(100),
+		DriverName:        "postgres.database.plural.sh",
+		ProvisionerClient: provisioner,
+	}, provisioner
+}
+
+func testGrantAccess(annotations map[string]string, finalizers ...string) *databasev1alpha1.DatabaseAccess {
+	return &databasev1alpha1.DatabaseAccess{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "payments", Annotations: annotations, Finalizers: finalizers},
+		Spec: databasev1alpha1.DatabaseAccessSpec{
+			DatabaseRequestName:     "orders",
+			DatabaseAccessClassName: "postgres",
+			CredentialsSecretName:   "orders-credentials",
+		},
+	}
+}
+
+func TestGrantAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		finalizers  []string
+		wantRevoked []string
+	}{
+		{name: "first grant"},
+		{
+			name:        "grant interrupted before the status update",
+			annotations: map[string]string{PendingAccountIDAnnotation: "account-app-0"},
+			finalizers:  []string{DatabaseAccessFinalizer},
+			wantRevoked: []string{"account-app-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, provisioner := newGrantTestReconciler(t, testGrantAccess(tt.annotations, tt.finalizers...))
+			ctx := context.Background()
+			key := client.ObjectKey{Name: "app", Namespace: "payments"}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(provisioner.revoked, tt.wantRevoked) {
+				t.Errorf("revoked %v, want %v", provisioner.revoked, tt.wantRevoked)
+			}
+			if want := []string{"account-app-1"}; !reflect.DeepEqual(provisioner.granted, want) {
+				t.Fatalf("granted %v, want %v", provisioner.granted, want)
+			}
+			databaseAccess := &databasev1alpha1.DatabaseAccess{}
+			if err := r.Get(ctx, key, databaseAccess); err != nil {
+				t.Fatal(err)
+			}
+			if !databaseAccess.Status.AccessGranted || databaseAccess.Status.AccountID != "account-app-1" {
+				t.Errorf("status %+v, want granted account-app-1", databaseAccess.Status)
+			}
+			if pending := databaseAccess.Annotations[PendingAccountIDAnnotation]; pending != "" {
+				t.Errorf("pending account %q left behind", pending)
+			}
+			if !controllerutil.ContainsFinalizer(databaseAccess, DatabaseAccessFinalizer) {
+				t.Error("finalizer missing")
+			}
+		})
+	}
+}
+
+func TestDeleteAccessWithPendingAccount(t *testing.T) {
+	// The access was deleted after the driver granted an account, before the account reached the status.
+	databaseAccess := testGrantAccess(map[string]string{PendingAccountIDAnnotation: "account-app-0"}, DatabaseAccessFinalizer)
+	r, provisioner := newGrantTestReconciler(t, databaseAccess)
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(databaseAccess)
+
+	if err := r.Delete(ctx, databaseAccess); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"account-app-0"}; !reflect.DeepEqual(provisioner.revoked, want) {
+		t.Errorf("revoked %v, want %v", provisioner.revoked, want)
+	}
+	if len(provisioner.granted) != 0 {
+		t.Errorf("granted %v for a deleted access", provisioner.granted)
+	}
+}
+
+func TestMissingDatabaseAccessClass(t *testing.T) {
+	// Accesses of a deleted class may belong to any driver, every sidecar leaves them alone without an error.
+	databaseAccess := testGrantAccess(nil, DatabaseAccessFinalizer)
+	databaseAccess.Spec.DatabaseAccessClassName = "deleted"
+	databaseAccess.Status = databasev1alpha1.DatabaseAccessStatus{AccessGranted: true, AccountID: "account-app-1"}
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, r *Reconciler, databaseAccess *databasev1alpha1.DatabaseAccess) error
+	}{
+		{name: "expiry", call: func(ctx context.Context, r *Reconciler, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+			_, _, err := r.reconcileExpiry(ctx, databaseAccess)
+			return err
+		}},
+		{name: "rotation", call: func(ctx context.Context, r *Reconciler, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+			_, err := r.reconcileRotation(ctx, databaseAccess)
+			return err
+		}},
+		{name: "service account", call: func(ctx context.Context, r *Reconciler, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+			databaseAccess.Annotations = map[string]string{ServiceAccountAnnotation: "deleted", ServiceAccountUIDAnnotation: "uid"}
+			_, err := r.reconcileServiceAccount(ctx, databaseAccess)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, provisioner := newGrantTestReconciler(t, databaseAccess.DeepCopy())
+			if err := tt.call(context.Background(), r, databaseAccess.DeepCopy()); err != nil {
+				t.Fatal(err)
+			}
+			if len(provisioner.granted) != 0 || len(provisioner.revoked) != 0 {
+				t.Errorf("granted %v, revoked %v", provisioner.granted, provisioner.revoked)
+			}
+		})
+	}
+}
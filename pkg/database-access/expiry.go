@@ -0,0 +1,179 @@
+package databaseaccess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TTLAnnotation limits the lifetime of a DatabaseAccess, counted from its creation, e.g. "8h".
+	TTLAnnotation = "database.plural.sh/ttl"
+	// ExpiresAtAnnotation sets the time a DatabaseAccess expires at, in RFC 3339 format.
+	ExpiresAtAnnotation = "database.plural.sh/expires-at"
+	// MaxTTLAnnotation limits the lifetime of all DatabaseAccesses of a DatabaseAccessClass, whether they set a TTL or not.
+	MaxTTLAnnotation = "database.plural.sh/max-ttl"
+
+	// ExpiredAtAnnotation marks a DatabaseAccess as expired. Its account was revoked and its Secret deleted.
+	ExpiredAtAnnotation = kubernetes.ExpiredAtAnnotation
+)
+
+// accessExpiry returns when the DatabaseAccess expires, the earliest of its TTL, its expiry time and the
+// maximum TTL of the DatabaseAccessClass. It returns the zero time for accesses without expiry.
+func accessExpiry(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (time.Time, error) {
+	created := databaseAccess.CreationTimestamp.Time
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	var expiresAt time.Time
+	earliest := func(t time.Time) {
+		if expiresAt.IsZero() || t.Before(expiresAt) {
+			expiresAt = t
+		}
+	}
+
+	if value, ok := databaseAccess.Annotations[TTLAnnotation]; ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return expiresAt, fmt.Errorf("invalid %s annotation: %w", TTLAnnotation, err)
+		}
+		earliest(created.Add(ttl))
+	}
+	if value, ok := databaseAccess.Annotations[ExpiresAtAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return expiresAt, fmt.Errorf("invalid %s annotation: %w", ExpiresAtAnnotation, err)
+		}
+		earliest(t)
+	}
+	maxTTL, err := getMaxTTL(databaseAccessClass)
+	if err != nil {
+		return expiresAt, err
+	}
+	if maxTTL > 0 {
+		earliest(created.Add(maxTTL))
+	}
+
+	return expiresAt, nil
+}
+
+func getMaxTTL(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (time.Duration, error) {
+	value, ok := databaseAccessClass.Annotations[MaxTTLAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	maxTTL, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation: %w", MaxTTLAnnotation, err)
+	}
+	return maxTTL, nil
+}
+
+// ValidateExpiry checks the TTL and expiry time of the DatabaseAccess against the maximum TTL of the DatabaseAccessClass.
+// Accesses without either expire after the maximum TTL.
+func ValidateExpiry(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+	expiresAt, err := accessExpiry(&databasev1alpha1.DatabaseAccessClass{}, databaseAccess)
+	if err != nil {
+		return err
+	}
+	maxTTL, err := getMaxTTL(databaseAccessClass)
+	if err != nil || maxTTL == 0 {
+		return err
+	}
+
+	created := databaseAccess.CreationTimestamp.Time
+	if created.IsZero() {
+		created = time.Now()
+	}
+	if !expiresAt.IsZero() && expiresAt.After(created.Add(maxTTL)) {
+		return fmt.Errorf("DatabaseAccessClass %s limits accesses to a TTL of %s", databaseAccessClass.Name, maxTTL)
+	}
+	return nil
+}
+
+// reconcileExpiry expires the DatabaseAccess once its expiry time has passed. It returns the expiry time,
+// the zero time for accesses without expiry, and true if the access expired.
+func (r *Reconciler) reconcileExpiry(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (time.Time, bool, error) {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	// Accesses without a class get the default class of the driver before they are granted.
+	if databaseAccess.Spec.DatabaseAccessClassName == "" {
+		return time.Time{}, false, nil
+	}
+	// Without the DatabaseAccessClass neither the driver nor the maximum TTL is known, the access is left as it is.
+	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.DatabaseAccessClassName}, databaseAccessClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return time.Time{}, false, nil
+		}
+		log.Error(err, "Failed to get DatabaseAccessClass")
+		return time.Time{}, false, err
+	}
+	if !strings.EqualFold(databaseAccessClass.DriverName, r.DriverName) {
+		return time.Time{}, false, nil
+	}
+
+	expiresAt, err := accessExpiry(databaseAccessClass, databaseAccess)
+	if err != nil {
+		log.Error(err, "Invalid expiry")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonAccessGrantFailed, err.Error())
+		return expiresAt, false, err
+	}
+	if expiresAt.IsZero() || time.Now().Before(expiresAt) {
+		return expiresAt, false, nil
+	}
+
+	if databaseAccess.Status.AccountID != "" {
+		database, err := r.getDatabase(ctx, databaseAccess)
+		if err != nil {
+			return expiresAt, false, err
+		}
+		// When the Database is already gone the account went away together with it.
+		if database != nil && database.Status.DatabaseID != "" {
+			if err := r.revokeAccounts(ctx, databaseAccess, database); err != nil {
+				return expiresAt, false, err
+			}
+		}
+	}
+	if err := r.deleteCredentials(ctx, databaseAccessClass, databaseAccess); err != nil {
+		return expiresAt, false, err
+	}
+	if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{
+		ExpiredAtAnnotation:               time.Now().UTC().Format(time.RFC3339),
+		PreviousAccountIDAnnotation:       "",
+		PreviousAccountRevokeAtAnnotation: "",
+		PendingAccountIDAnnotation:        "",
+	}); err != nil {
+		return expiresAt, false, err
+	}
+
+	databaseAccess.Status.AccessGranted = false
+	databaseAccess.Status.AccountID = ""
+	if err := r.Status().Update(ctx, databaseAccess); err != nil {
+		return expiresAt, false, err
+	}
+	log.Info("DatabaseAccess expired", "expiresAt", expiresAt)
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessExpired, "Access expired at %s", expiresAt.UTC().Format(time.RFC3339))
+
+	return expiresAt, true, nil
+}
+
+// requeueBefore returns the result, requeuing no later than the given time if it is set.
+func requeueBefore(now time.Time, result ctrl.Result, deadline time.Time) ctrl.Result {
+	if deadline.IsZero() || result.Requeue && result.RequeueAfter == 0 {
+		return result
+	}
+	if result.RequeueAfter > 0 {
+		return requeueAt(now, now.Add(result.RequeueAfter), deadline)
+	}
+	return requeueAt(now, deadline)
+}
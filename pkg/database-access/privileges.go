@@ -0,0 +1,237 @@
+package databaseaccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RoleAnnotation requests one of the predefined privilege levels for a DatabaseAccess.
+	// The role is passed to the driver as grant parameter of the same name.
+	RoleAnnotation = "database.plural.sh/role"
+	// GrantsAnnotation holds a YAML list of additional grants for a DatabaseAccess, in the syntax of the driver.
+	// The grants are passed to the driver as grant parameter of the same name, encoded as JSON list.
+	GrantsAnnotation = "database.plural.sh/grants"
+
+	// AllowedRolesAnnotation holds a comma separated list of the roles DatabaseAccesses of a DatabaseAccessClass may request.
+	AllowedRolesAnnotation = "database.plural.sh/allowed-roles"
+	// AllowedGrantsAnnotation holds a YAML list of glob patterns for the grants DatabaseAccesses of a DatabaseAccessClass may request.
+	AllowedGrantsAnnotation = "database.plural.sh/allowed-grants"
+
+	// AppliedPrivilegesAnnotation records the privileges the current account was granted with.
+	AppliedPrivilegesAnnotation = "database.plural.sh/applied-privileges"
+
+	RoleReadOnly  = "read-only"
+	RoleReadWrite = "read-write"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the predefined privilege levels.
+var Roles = []string{RoleReadOnly, RoleReadWrite, RoleAdmin}
+
+// privileges are the role and grants requested by a DatabaseAccess.
+type privileges struct {
+	Role   string   `json:"role,omitempty"`
+	Grants []string `json:"grants,omitempty"`
+}
+
+// getPrivileges returns the privileges requested by the DatabaseAccess, after checking them against the
+// allow-lists of the DatabaseAccessClass. Without allow-lists no role or grants can be requested.
+func getPrivileges(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (privileges, error) {
+	var requested privileges
+	requested.Role = databaseAccess.Annotations[RoleAnnotation]
+	var grants []string
+	if value, ok := databaseAccess.Annotations[GrantsAnnotation]; ok {
+		if err := yaml.Unmarshal([]byte(value), &grants); err != nil {
+			return requested, fmt.Errorf("invalid %s annotation: %w", GrantsAnnotation, err)
+		}
+	}
+
+	allowedRoles, allowedGrants, err := allowedPrivileges(databaseAccessClass)
+	if err != nil {
+		return requested, err
+	}
+
+	if requested.Role != "" {
+		if !contains(Roles, requested.Role) {
+			return requested, fmt.Errorf("invalid %s annotation: role %q must be one of %s", RoleAnnotation, requested.Role, strings.Join(Roles, ", "))
+		}
+		if !contains(allowedRoles, requested.Role) {
+			return requested, fmt.Errorf("role %q is not allowed by DatabaseAccessClass %s", requested.Role, databaseAccessClass.Name)
+		}
+	}
+	for _, value := range grants {
+		g, err := parseGrant(value, false)
+		if err != nil {
+			return requested, fmt.Errorf("invalid %s annotation: %w", GrantsAnnotation, err)
+		}
+		if !matchesAny(allowedGrants, g) {
+			return requested, fmt.Errorf("grant %q is not allowed by DatabaseAccessClass %s", value, databaseAccessClass.Name)
+		}
+		// Only the normalized grant is passed to the driver.
+		requested.Grants = append(requested.Grants, g.String())
+	}
+
+	return requested, nil
+}
+
+// allowedPrivileges parses the allow-lists of the DatabaseAccessClass.
+func allowedPrivileges(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) ([]string, []grant, error) {
+	var allowedRoles []string
+	for _, role := range strings.Split(databaseAccessClass.Annotations[AllowedRolesAnnotation], ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !contains(Roles, role) {
+			return nil, nil, fmt.Errorf("invalid %s annotation: role %q must be one of %s", AllowedRolesAnnotation, role, strings.Join(Roles, ", "))
+		}
+		allowedRoles = append(allowedRoles, role)
+	}
+
+	var allowedGrants []grant
+	if value, ok := databaseAccessClass.Annotations[AllowedGrantsAnnotation]; ok {
+		var patterns []string
+		if err := yaml.Unmarshal([]byte(value), &patterns); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s annotation: %w", AllowedGrantsAnnotation, err)
+		}
+		for _, pattern := range patterns {
+			g, err := parseGrant(pattern, true)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid %s annotation: %w", AllowedGrantsAnnotation, err)
+			}
+			allowedGrants = append(allowedGrants, g)
+		}
+	}
+
+	return allowedRoles, allowedGrants, nil
+}
+
+// ValidatePrivileges checks the privileges requested by the DatabaseAccess against the DatabaseAccessClass.
+func ValidatePrivileges(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) error {
+	_, err := getPrivileges(databaseAccessClass, databaseAccess)
+	return err
+}
+
+// parameters returns the grant parameters for the privileges.
+func (p privileges) parameters() map[string]string {
+	parameters := map[string]string{}
+	if p.Role != "" {
+		parameters[RoleAnnotation] = p.Role
+	}
+	if len(p.Grants) > 0 {
+		grants, _ := json.Marshal(p.Grants)
+		parameters[GrantsAnnotation] = string(grants)
+	}
+	return parameters
+}
+
+// String returns the privileges as recorded in AppliedPrivilegesAnnotation, empty privileges return an empty string.
+func (p privileges) String() string {
+	if p.Role == "" && len(p.Grants) == 0 {
+		return ""
+	}
+	value, _ := json.Marshal(p)
+	return string(value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// grant is a single privilege on a database object, e.g. "SELECT ON payments.orders".
+type grant struct {
+	// Privilege holds the upper case privilege words, e.g. "ALL PRIVILEGES".
+	Privilege string
+	// Object holds the dot separated parts of the object name.
+	Object []string
+}
+
+const grantWildcard = "*"
+
+var (
+	privilegeWordRegexp = regexp.MustCompile(`^[A-Za-z]+$`)
+	identifierRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// parseGrant parses a grant of the form "<PRIVILEGE> ON <object>". Grants are passed on to drivers that
+// build SQL from them, so anything but plain words and identifiers, like separators, quotes or comments,
+// is rejected. Patterns may use "*" for the privilege and for whole parts of the object name.
+func parseGrant(value string, pattern bool) (grant, error) {
+	var g grant
+	fields := strings.Fields(value)
+	on := -1
+	for i, field := range fields {
+		if strings.EqualFold(field, "ON") {
+			if on != -1 {
+				return g, fmt.Errorf("grant %q must have the form \"<PRIVILEGE> ON <object>\"", value)
+			}
+			on = i
+		}
+	}
+	if on < 1 || on != len(fields)-2 {
+		return g, fmt.Errorf("grant %q must have the form \"<PRIVILEGE> ON <object>\"", value)
+	}
+
+	words := fields[:on]
+	if pattern && len(words) == 1 && words[0] == grantWildcard {
+		g.Privilege = grantWildcard
+	} else {
+		for _, word := range words {
+			if !privilegeWordRegexp.MatchString(word) {
+				return g, fmt.Errorf("grant %q: invalid privilege %q", value, word)
+			}
+		}
+		g.Privilege = strings.ToUpper(strings.Join(words, " "))
+	}
+
+	for _, part := range strings.Split(fields[on+1], ".") {
+		if !identifierRegexp.MatchString(part) && !(pattern && part == grantWildcard) {
+			return g, fmt.Errorf("grant %q: invalid object name %q", value, fields[on+1])
+		}
+		g.Object = append(g.Object, part)
+	}
+
+	return g, nil
+}
+
+// String returns the normalized grant.
+func (g grant) String() string {
+	return g.Privilege + " ON " + strings.Join(g.Object, ".")
+}
+
+// matches returns true if the grant is allowed by the pattern. Privileges and object name parts are compared
+// as whole tokens, "*" matches any privilege or a single part of the object name.
+func (g grant) matches(pattern grant) bool {
+	if pattern.Privilege != grantWildcard && pattern.Privilege != g.Privilege {
+		return false
+	}
+	if len(pattern.Object) != len(g.Object) {
+		return false
+	}
+	for i, part := range pattern.Object {
+		if part != grantWildcard && part != g.Object[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(patterns []grant, g grant) bool {
+	for _, pattern := range patterns {
+		if g.matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
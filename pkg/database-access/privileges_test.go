@@ -0,0 +1,269 @@
+package databaseaccess
+
+import (
+	"reflect"
+	"testing"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPrivileges(t *testing.T) {
+	tests := []struct {
+		name  string
+		class map[string]string
+		// access holds the annotations of the DatabaseAccess.
+		access  map[string]string
+		want    privileges
+		wantErr bool
+	}{
+		{
+			name:   "nothing requested without allow-lists",
+			access: map[string]string{},
+		},
+		{
+			name:    "role denied without allow-list",
+			access:  map[string]string{RoleAnnotation: RoleReadOnly},
+			wantErr: true,
+		},
+		{
+			name:    "grant denied without allow-list",
+			access:  map[string]string{GrantsAnnotation: `["SELECT ON orders"]`},
+			wantErr: true,
+		},
+		{
+			name:    "grant denied by empty allow-list",
+			class:   map[string]string{AllowedGrantsAnnotation: `[]`},
+			access:  map[string]string{GrantsAnnotation: `["SELECT ON orders"]`},
+			wantErr: true,
+		},
+		{
+			name:   "allowed role",
+			class:  map[string]string{AllowedRolesAnnotation: "read-only, read-write"},
+			access: map[string]string{RoleAnnotation: RoleReadWrite},
+			want:   privileges{Role: RoleReadWrite},
+		},
+		{
+			name:    "role not allowed",
+			class:   map[string]string{AllowedRolesAnnotation: "read-only"},
+			access:  map[string]string{RoleAnnotation: RoleAdmin},
+			wantErr: true,
+		},
+		{
+			name:    "unknown role",
+			class:   map[string]string{AllowedRolesAnnotation: "read-only"},
+			access:  map[string]string{RoleAnnotation: "superuser"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown allowed role",
+			class:   map[string]string{AllowedRolesAnnotation: "read-only,superuser"},
+			access:  map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:   "exact grant",
+			class:  map[string]string{AllowedGrantsAnnotation: `["SELECT ON orders"]`},
+			access: map[string]string{GrantsAnnotation: "- SELECT ON orders"},
+			want:   privileges{Grants: []string{"SELECT ON orders"}},
+		},
+		{
+			name:    "exact grant does not match prefix",
+			class:   map[string]string{AllowedGrantsAnnotation: `["SELECT ON orders"]`},
+			access:  map[string]string{GrantsAnnotation: `["SELECT ON orders_archive"]`},
+			wantErr: true,
+		},
+		{
+			name:   "wildcard grant",
+			class:  map[string]string{AllowedGrantsAnnotation: `["SELECT ON *", "INSERT ON orders"]`},
+			access: map[string]string{GrantsAnnotation: `["SELECT ON customers", "INSERT ON orders"]`},
+			want:   privileges{Grants: []string{"SELECT ON customers", "INSERT ON orders"}},
+		},
+		{
+			name:   "normalized grant",
+			class:  map[string]string{AllowedGrantsAnnotation: `["select on payments.*"]`},
+			access: map[string]string{GrantsAnnotation: `["Select  on payments.orders"]`},
+			want:   privileges{Grants: []string{"SELECT ON payments.orders"}},
+		},
+		{
+			name:    "one grant not allowed",
+			class:   map[string]string{AllowedGrantsAnnotation: `["SELECT ON *"]`},
+			access:  map[string]string{GrantsAnnotation: `["SELECT ON customers", "DELETE ON customers"]`},
+			wantErr: true,
+		},
+		{
+			name:   "any privilege",
+			class:  map[string]string{AllowedGrantsAnnotation: `["* ON payments.*"]`},
+			access: map[string]string{GrantsAnnotation: `["ALL PRIVILEGES ON payments.orders"]`},
+			want:   privileges{Grants: []string{"ALL PRIVILEGES ON payments.orders"}},
+		},
+		{
+			name:    "injection behind allowed grant",
+			class:   map[string]string{AllowedGrantsAnnotation: `["SELECT ON *"]`},
+			access:  map[string]string{GrantsAnnotation: `["SELECT ON t TO x; GRANT ALL ON db TO y"]`},
+			wantErr: true,
+		},
+		{
+			name:    "malformed pattern",
+			class:   map[string]string{AllowedGrantsAnnotation: `["SELECT ON [orders"]`},
+			access:  map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "pattern without object",
+			class:   map[string]string{AllowedGrantsAnnotation: `["*"]`},
+			access:  map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "malformed allow-list",
+			class:   map[string]string{AllowedGrantsAnnotation: `SELECT: [`},
+			access:  map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "malformed grants",
+			class:   map[string]string{AllowedGrantsAnnotation: `["SELECT ON *"]`},
+			access:  map[string]string{GrantsAnnotation: `{"SELECT": "orders"}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{ObjectMeta: metav1.ObjectMeta{Name: "class", Annotations: tt.class}}
+			databaseAccess := &databasev1alpha1.DatabaseAccess{ObjectMeta: metav1.ObjectMeta{Name: "access", Annotations: tt.access}}
+			got, err := getPrivileges(databaseAccessClass, databaseAccess)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGrant(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		pattern bool
+		want    string
+		wantErr bool
+	}{
+		{name: "simple", value: "SELECT ON orders", want: "SELECT ON orders"},
+		{name: "qualified object", value: "SELECT ON payments.orders", want: "SELECT ON payments.orders"},
+		{name: "multiple privilege words", value: "ALL PRIVILEGES ON payments", want: "ALL PRIVILEGES ON payments"},
+		{name: "case and whitespace", value: " select\ton  payments.orders ", want: "SELECT ON payments.orders"},
+		{name: "wildcards in pattern", value: "* ON payments.*", pattern: true, want: "* ON payments.*"},
+		{name: "wildcard privilege in grant", value: "* ON payments", wantErr: true},
+		{name: "wildcard object in grant", value: "SELECT ON payments.*", wantErr: true},
+		{name: "partial wildcard", value: "SELECT ON pay*", pattern: true, wantErr: true},
+		{name: "missing object", value: "SELECT ON", wantErr: true},
+		{name: "missing privilege", value: "ON orders", wantErr: true},
+		{name: "missing ON", value: "SELECT orders", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+		{name: "empty object part", value: "SELECT ON payments..orders", wantErr: true},
+		{name: "statement separator", value: "SELECT ON t; GRANT ALL ON db TO y", wantErr: true},
+		{name: "separator without space", value: "SELECT ON t;DROP", wantErr: true},
+		{name: "grantee", value: "SELECT ON t TO x", wantErr: true},
+		{name: "grant option", value: "SELECT ON t WITH GRANT OPTION", wantErr: true},
+		{name: "second ON", value: "SELECT ON t ON u", wantErr: true},
+		{name: "double quotes", value: `SELECT ON "t"`, wantErr: true},
+		{name: "single quotes", value: "SELECT ON 't'", wantErr: true},
+		{name: "backticks", value: "SELECT ON `t`", wantErr: true},
+		{name: "line comment", value: "SELECT ON t--", wantErr: true},
+		{name: "block comment", value: "SELECT ON t/**/", wantErr: true},
+		{name: "comment in privilege", value: "SELECT/**/ ON t", wantErr: true},
+		{name: "column list", value: "SELECT (id) ON t", wantErr: true},
+		{name: "privilege list", value: "SELECT, DELETE ON t", wantErr: true},
+		{name: "newline", value: "SELECT ON t\nGRANT ALL ON db", wantErr: true},
+		{name: "non-ASCII", value: "SELECT ON tаble", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGrant(tt.value, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		value    string
+		want     bool
+	}{
+		{name: "no patterns", value: "SELECT ON orders"},
+		{name: "exact", patterns: []string{"SELECT ON orders"}, value: "SELECT ON orders", want: true},
+		{name: "privilege case", patterns: []string{"SELECT ON orders"}, value: "select on orders", want: true},
+		{name: "object case", patterns: []string{"SELECT ON orders"}, value: "SELECT ON Orders"},
+		{name: "object prefix", patterns: []string{"SELECT ON orders"}, value: "SELECT ON orders_archive"},
+		{name: "wildcard object", patterns: []string{"SELECT ON *"}, value: "SELECT ON orders", want: true},
+		{name: "wildcard object other privilege", patterns: []string{"SELECT ON *"}, value: "INSERT ON orders"},
+		{name: "wildcard object part", patterns: []string{"SELECT ON payments.*"}, value: "SELECT ON payments.orders", want: true},
+		{name: "wildcard object other schema", patterns: []string{"SELECT ON payments.*"}, value: "SELECT ON billing.orders"},
+		{name: "wildcard matches a single part", patterns: []string{"SELECT ON *"}, value: "SELECT ON payments.orders"},
+		{name: "wildcard privilege", patterns: []string{"* ON orders"}, value: "ALL PRIVILEGES ON orders", want: true},
+		{name: "privilege words compared whole", patterns: []string{"ALL ON orders"}, value: "ALL PRIVILEGES ON orders"},
+		{name: "second pattern", patterns: []string{"INSERT ON *", "SELECT ON *"}, value: "SELECT ON orders", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patterns []grant
+			for _, value := range tt.patterns {
+				pattern, err := parseGrant(value, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				patterns = append(patterns, pattern)
+			}
+			g, err := parseGrant(tt.value, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchesAny(patterns, g); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivilegesParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		privileges privileges
+		want       map[string]string
+		wantString string
+	}{
+		{name: "empty", privileges: privileges{}, want: map[string]string{}},
+		{
+			name:       "role",
+			privileges: privileges{Role: RoleReadOnly},
+			want:       map[string]string{RoleAnnotation: RoleReadOnly},
+			wantString: `{"role":"read-only"}`,
+		},
+		{
+			name:       "role and grants",
+			privileges: privileges{Role: RoleAdmin, Grants: []string{"SELECT ON orders"}},
+			want:       map[string]string{RoleAnnotation: RoleAdmin, GrantsAnnotation: `["SELECT ON orders"]`},
+			wantString: `{"role":"admin","grants":["SELECT ON orders"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.privileges.parameters(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parameters %v, want %v", got, tt.want)
+			}
+			if got := tt.privileges.String(); got != tt.wantString {
+				t.Errorf("string %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
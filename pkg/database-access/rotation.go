@@ -0,0 +1,305 @@
+package databaseaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RotationIntervalAnnotation enables periodic credential rotation. It can be set on the
+	// DatabaseAccessClass and overridden on the DatabaseAccess, e.g. "720h".
+	RotationIntervalAnnotation = "database.plural.sh/rotation-interval"
+	// RotationOverlapAnnotation defines how long the previous account keeps working after a rotation.
+	RotationOverlapAnnotation = "database.plural.sh/rotation-overlap"
+	// RotateAnnotation triggers a rotation every time its value changes.
+	RotateAnnotation = "database.plural.sh/rotate"
+
+	// LastRotationTimeAnnotation records when the credentials were last rotated.
+	LastRotationTimeAnnotation = "database.plural.sh/last-rotation-time"
+	// LastRotateAnnotation records the value of RotateAnnotation handled by the last rotation.
+	LastRotateAnnotation = "database.plural.sh/last-rotate"
+	// PreviousAccountIDAnnotation holds the account replaced by the last rotation until it is revoked.
+	PreviousAccountIDAnnotation = "database.plural.sh/previous-account-id"
+	// PreviousAccountRevokeAtAnnotation records when the previous account is revoked.
+	PreviousAccountRevokeAtAnnotation = "database.plural.sh/previous-account-revoke-at"
+	// PendingAccountIDAnnotation holds the account granted by a grant or rotation until it is stored in the status.
+	PendingAccountIDAnnotation = "database.plural.sh/pending-account-id"
+
+	defaultRotationOverlap = 10 * time.Minute
+)
+
+type rotationPolicy struct {
+	interval time.Duration
+	overlap  time.Duration
+}
+
+func getRotationPolicy(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (rotationPolicy, error) {
+	policy := rotationPolicy{overlap: defaultRotationOverlap}
+
+	for _, annotations := range []map[string]string{databaseAccessClass.Annotations, databaseAccess.Annotations} {
+		if value, ok := annotations[RotationIntervalAnnotation]; ok {
+			interval, err := time.ParseDuration(value)
+			if err != nil {
+				return policy, fmt.Errorf("invalid %s annotation: %w", RotationIntervalAnnotation, err)
+			}
+			policy.interval = interval
+		}
+		if value, ok := annotations[RotationOverlapAnnotation]; ok {
+			overlap, err := time.ParseDuration(value)
+			if err != nil {
+				return policy, fmt.Errorf("invalid %s annotation: %w", RotationOverlapAnnotation, err)
+			}
+			policy.overlap = overlap
+		}
+	}
+
+	return policy, nil
+}
+
+// reconcileRotation re-issues the credentials of a granted DatabaseAccess when its rotation policy
+// says so and revokes the previous account once the overlap window has passed.
+func (r *Reconciler) reconcileRotation(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (ctrl.Result, error) {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	// Without the DatabaseAccessClass neither the driver nor the rotation policy is known.
+	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.DatabaseAccessClassName}, databaseAccessClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get DatabaseAccessClass")
+		return ctrl.Result{}, err
+	}
+	if !strings.EqualFold(databaseAccessClass.DriverName, r.DriverName) {
+		return ctrl.Result{}, nil
+	}
+
+	policy, err := getRotationPolicy(databaseAccessClass, databaseAccess)
+	if err != nil {
+		log.Error(err, "Invalid rotation policy")
+		return ctrl.Result{}, err
+	}
+
+	// Changed privileges are applied by rotating to an account granted with the new privileges.
+	privileges, err := getPrivileges(databaseAccessClass, databaseAccess)
+	if err != nil {
+		log.Error(err, "Invalid privileges")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonPrivilegesNotAllowed, err.Error())
+		return ctrl.Result{}, err
+	}
+	if databaseAccess.Annotations[PendingAccountIDAnnotation] != "" {
+		if err := r.resumeRotation(ctx, databaseAccess, privileges); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	now := time.Now()
+	rotateRequested := databaseAccess.Annotations[RotateAnnotation] != "" &&
+		databaseAccess.Annotations[RotateAnnotation] != databaseAccess.Annotations[LastRotateAnnotation]
+	previousAccountID := databaseAccess.Annotations[PreviousAccountIDAnnotation]
+
+	var nextRotation time.Time
+	if policy.interval > 0 {
+		lastRotation := databaseAccess.CreationTimestamp.Time
+		if value := databaseAccess.Annotations[LastRotationTimeAnnotation]; value != "" {
+			if lastRotation, err = time.Parse(time.RFC3339, value); err != nil {
+				log.Error(err, "Invalid last rotation time")
+				return ctrl.Result{}, err
+			}
+		}
+		nextRotation = lastRotation.Add(policy.interval)
+	}
+	privilegesChanged := privileges.String() != databaseAccess.Annotations[AppliedPrivilegesAnnotation]
+	rotationDue := rotateRequested || privilegesChanged || (!nextRotation.IsZero() && !now.Before(nextRotation))
+
+	var revokeAt time.Time
+	if previousAccountID != "" {
+		if revokeAt, err = time.Parse(time.RFC3339, databaseAccess.Annotations[PreviousAccountRevokeAtAnnotation]); err != nil {
+			// Without a valid deadline the previous account is revoked right away.
+			revokeAt = now
+		}
+	}
+
+	if !rotationDue && (previousAccountID == "" || now.Before(revokeAt)) {
+		return requeueAt(now, nextRotation, revokeAt), nil
+	}
+
+	database, err := r.getDatabase(ctx, databaseAccess)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if database == nil || database.Status.DatabaseID == "" {
+		err := errors.New("Credentials can't be rotated for a database without a databaseID")
+		return ctrl.Result{}, err
+	}
+
+	// The previous account is revoked before a new rotation starts, so only one old account is ever tracked.
+	if previousAccountID != "" && (rotationDue || !now.Before(revokeAt)) {
+		if previousAccountID != databaseAccess.Status.AccountID {
+			if err := r.revokeAccess(ctx, database, previousAccountID); err != nil {
+				log.Error(err, "Driver failed to revoke previous access", "AccountID", previousAccountID)
+				r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonAccessRevokeFailed, "Driver failed to revoke previous account %s: %v", previousAccountID, err)
+				return ctrl.Result{}, err
+			}
+			log.Info("Successfully revoked previous access", "AccountID", previousAccountID)
+			r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessRevoked, "Revoked previous account %s", previousAccountID)
+		}
+		if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{
+			PreviousAccountIDAnnotation:       "",
+			PreviousAccountRevokeAtAnnotation: "",
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		revokeAt = time.Time{}
+	}
+
+	if !rotationDue {
+		return requeueAt(now, nextRotation, revokeAt), nil
+	}
+
+	templates, err := secretTemplates(databaseAccessClass)
+	if err != nil {
+		log.Error(err, "Invalid secret templates in DatabaseAccessClass")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonSecretTemplateFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+	sink, err := r.credentialSink(databaseAccessClass)
+	if err != nil {
+		log.Error(err, "Invalid credential sink in DatabaseAccessClass")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	accountName := fmt.Sprintf("account-%s-%d", databaseAccess.Name, now.Unix())
+	rsp, err := r.grantAccess(ctx, database, databaseAccessClass, databaseAccess, accountName)
+	if err != nil {
+		log.Error(err, "Failed to grant rotated access")
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, grantFailedReason(err), "Driver failed to grant rotated access: %v", err)
+		return ctrl.Result{}, err
+	}
+	secretData, err := renderSecretData(templates, credentialsData(databaseAccessClass, databaseAccess, rsp))
+	if err != nil {
+		log.Error(err, "Failed to render credential secret")
+		r.Recorder.Event(databaseAccess, corev1.EventTypeWarning, ReasonSecretTemplateFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// The new account is recorded before anything else, so an interrupted rotation can be resumed or rolled back.
+	revokeAt = now.Add(policy.overlap)
+	if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{
+		PendingAccountIDAnnotation:        rsp.AccountId,
+		PreviousAccountIDAnnotation:       databaseAccess.Status.AccountID,
+		PreviousAccountRevokeAtAnnotation: revokeAt.Format(time.RFC3339),
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+	if _, err := r.writeCredentials(ctx, sink, databaseAccess, secretData); err != nil {
+		log.Error(err, "Failed to write rotated credentials", "location", sink.Location(databaseAccess))
+		r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonCredentialSinkFailed, "Failed to write rotated credentials to %s: %v", sink.Location(databaseAccess), err)
+		return ctrl.Result{}, err
+	}
+
+	databaseAccess.Status.AccountID = rsp.AccountId
+	if err := r.Status().Update(ctx, databaseAccess); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.completeRotation(ctx, databaseAccess, privileges, now); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("Successfully rotated credentials", "AccountID", rsp.AccountId)
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonCredentialsRotated, "Rotated credentials to account %s", rsp.AccountId)
+
+	if policy.interval > 0 {
+		nextRotation = now.Add(policy.interval)
+	}
+	return requeueAt(now, nextRotation, revokeAt), nil
+}
+
+// resumeRotation finishes a grant or rotation that was interrupted after the new account was granted. If the new account
+// was not stored in the status yet it is revoked and the grant or rotation starts over, otherwise the rotation is completed.
+func (r *Reconciler) resumeRotation(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, privileges privileges) error {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	pendingAccountID := databaseAccess.Annotations[PendingAccountIDAnnotation]
+	if pendingAccountID == databaseAccess.Status.AccountID {
+		log.Info("Completing interrupted rotation", "AccountID", pendingAccountID)
+		return r.completeRotation(ctx, databaseAccess, privileges, time.Now())
+	}
+
+	database, err := r.getDatabase(ctx, databaseAccess)
+	if err != nil {
+		return err
+	}
+	if database != nil && database.Status.DatabaseID != "" {
+		if err := r.revokeAccess(ctx, database, pendingAccountID); err != nil {
+			log.Error(err, "Driver failed to revoke account of interrupted rotation", "AccountID", pendingAccountID)
+			r.Recorder.Eventf(databaseAccess, corev1.EventTypeWarning, ReasonAccessRevokeFailed, "Driver failed to revoke account %s of interrupted rotation: %v", pendingAccountID, err)
+			return err
+		}
+	}
+	log.Info("Revoked account of interrupted rotation", "AccountID", pendingAccountID)
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessRevoked, "Revoked account %s of interrupted rotation", pendingAccountID)
+
+	// The previous account recorded by the interrupted rotation is still the current one.
+	return r.patchAnnotations(ctx, databaseAccess, map[string]string{
+		PendingAccountIDAnnotation:        "",
+		PreviousAccountIDAnnotation:       "",
+		PreviousAccountRevokeAtAnnotation: "",
+	})
+}
+
+// completeRotation records a rotation whose account is stored in the status.
+func (r *Reconciler) completeRotation(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, privileges privileges, now time.Time) error {
+	return r.patchAnnotations(ctx, databaseAccess, map[string]string{
+		PendingAccountIDAnnotation:  "",
+		LastRotationTimeAnnotation:  now.Format(time.RFC3339),
+		LastRotateAnnotation:        databaseAccess.Annotations[RotateAnnotation],
+		AppliedPrivilegesAnnotation: privileges.String(),
+	})
+}
+
+// patchAnnotations sets the given annotations on the object, empty values remove the annotation.
+func (r *Reconciler) patchAnnotations(ctx context.Context, obj client.Object, annotations map[string]string) error {
+	original := obj.DeepCopyObject().(client.Object)
+
+	current := obj.GetAnnotations()
+	if current == nil {
+		current = map[string]string{}
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(current, key)
+			continue
+		}
+		current[key] = value
+	}
+	obj.SetAnnotations(current)
+
+	return r.Patch(ctx, obj, client.MergeFrom(original))
+}
+
+// requeueAt returns a result requeuing at the earliest of the given non-zero times.
+func requeueAt(now time.Time, times ...time.Time) ctrl.Result {
+	var next time.Time
+	for _, t := range times {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	if next.IsZero() {
+		return ctrl.Result{}
+	}
+	if !next.After(now) {
+		return ctrl.Result{Requeue: true}
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now)}
+}
@@ -0,0 +1,139 @@
+package databaseaccess
+
+import (
+	"context"
+	"strings"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ServiceAccountAnnotation names a ServiceAccount in the namespace of the DatabaseAccess. The access is granted
+	// to the identity of the ServiceAccount with the IAM authentication type instead of a static password.
+	ServiceAccountAnnotation = "database.plural.sh/service-account"
+	// ServiceAccountAudienceAnnotation sets the audience of the projected ServiceAccount token the driver trusts.
+	// It can be set on the DatabaseAccessClass and overridden on the DatabaseAccess, and is passed to the driver
+	// as grant parameter of the same name.
+	ServiceAccountAudienceAnnotation = "database.plural.sh/service-account-audience"
+	// ServiceAccountUIDAnnotation records the ServiceAccount the access was granted to, a re-created
+	// ServiceAccount of the same name gets a new grant.
+	ServiceAccountUIDAnnotation = "database.plural.sh/service-account-uid"
+
+	// ServiceAccountNamespaceKey and ServiceAccountNameKey are passed to the driver as grant parameters.
+	ServiceAccountNamespaceKey = "database.plural.sh/service-account-namespace"
+	ServiceAccountNameKey      = "database.plural.sh/service-account-name"
+)
+
+// serviceAccountIdentity returns the identity parameters of a DatabaseAccess bound to a ServiceAccount, or nil.
+func serviceAccountIdentity(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) map[string]string {
+	name := databaseAccess.Annotations[ServiceAccountAnnotation]
+	if name == "" {
+		return nil
+	}
+
+	identity := map[string]string{
+		ServiceAccountNamespaceKey: databaseAccess.Namespace,
+		ServiceAccountNameKey:      name,
+	}
+	for _, annotations := range []map[string]string{databaseAccessClass.Annotations, databaseAccess.Annotations} {
+		if audience := annotations[ServiceAccountAudienceAnnotation]; audience != "" {
+			identity[ServiceAccountAudienceAnnotation] = audience
+		}
+	}
+	return identity
+}
+
+// getServiceAccount returns the ServiceAccount the DatabaseAccess is bound to, or nil if it does not exist.
+func (r *Reconciler) getServiceAccount(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (*corev1.ServiceAccount, error) {
+	serviceAccount := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccess.Annotations[ServiceAccountAnnotation], Namespace: databaseAccess.Namespace}, serviceAccount); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return serviceAccount, nil
+}
+
+// reconcileServiceAccount revokes the access of a DatabaseAccess whose ServiceAccount was deleted or re-created.
+// The credentials Secret is deleted as well, the access is granted again once the ServiceAccount exists.
+// It returns true if the access was revoked.
+func (r *Reconciler) reconcileServiceAccount(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (bool, error) {
+	log := r.Log.WithValues("DatabaseAccess", client.ObjectKeyFromObject(databaseAccess))
+
+	// Without the DatabaseAccessClass the driver serving the access is not known.
+	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.DatabaseAccessClassName}, databaseAccessClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		log.Error(err, "Failed to get DatabaseAccessClass")
+		return false, err
+	}
+	if !strings.EqualFold(databaseAccessClass.DriverName, r.DriverName) {
+		return false, nil
+	}
+
+	serviceAccount, err := r.getServiceAccount(ctx, databaseAccess)
+	if err != nil {
+		return false, err
+	}
+	if serviceAccount != nil && string(serviceAccount.UID) == databaseAccess.Annotations[ServiceAccountUIDAnnotation] {
+		return false, nil
+	}
+
+	database, err := r.getDatabase(ctx, databaseAccess)
+	if err != nil {
+		return false, err
+	}
+	// Accounts left over from a rotation were granted to the same identity.
+	if database != nil && database.Status.DatabaseID != "" {
+		if err := r.revokeAccounts(ctx, databaseAccess, database); err != nil {
+			return false, err
+		}
+	}
+	if err := r.deleteCredentials(ctx, databaseAccessClass, databaseAccess); err != nil {
+		return false, err
+	}
+	if err := r.patchAnnotations(ctx, databaseAccess, map[string]string{
+		ServiceAccountUIDAnnotation:       "",
+		PreviousAccountIDAnnotation:       "",
+		PreviousAccountRevokeAtAnnotation: "",
+		PendingAccountIDAnnotation:        "",
+	}); err != nil {
+		return false, err
+	}
+
+	accountID := databaseAccess.Status.AccountID
+	databaseAccess.Status.AccessGranted = false
+	databaseAccess.Status.AccountID = ""
+	if err := r.Status().Update(ctx, databaseAccess); err != nil {
+		return false, err
+	}
+	log.Info("Revoked access of removed ServiceAccount", "AccountID", accountID)
+	r.Recorder.Eventf(databaseAccess, corev1.EventTypeNormal, ReasonAccessRevoked,
+		"Revoked account %s, ServiceAccount %s was deleted or replaced", accountID, databaseAccess.Annotations[ServiceAccountAnnotation])
+
+	return true, nil
+}
+
+// accessesForServiceAccount maps a ServiceAccount to the DatabaseAccesses bound to it.
+func (r *Reconciler) accessesForServiceAccount(obj client.Object) []reconcile.Request {
+	var databaseAccessList databasev1alpha1.DatabaseAccessList
+	if err := r.List(context.Background(), &databaseAccessList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list DatabaseAccesses", "ServiceAccount", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, databaseAccess := range databaseAccessList.Items {
+		if databaseAccess.Annotations[ServiceAccountAnnotation] == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&databaseAccess)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,173 @@
+package databaseaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CredentialSinkAnnotation selects where the credentials of the accesses of a DatabaseAccessClass are written.
+	CredentialSinkAnnotation = "database.plural.sh/credential-sink"
+
+	// AppliedCredentialSinkAnnotation records the sink the credentials of a DatabaseAccess were written to. The owner of
+	// the access can edit it, so it only selects the sink, the location in Vault is taken from the DatabaseAccessClass.
+	AppliedCredentialSinkAnnotation = "database.plural.sh/applied-credential-sink"
+
+	CredentialSinkSecret = "secret"
+	CredentialSinkVault  = "vault"
+)
+
+// CredentialSinks lists the supported values of the CredentialSinkAnnotation.
+var CredentialSinks = []string{CredentialSinkSecret, CredentialSinkVault}
+
+// CredentialSink stores the credentials of DatabaseAccesses.
+type CredentialSink interface {
+	// Write creates or replaces the credentials of the DatabaseAccess. It returns true if they did not exist before.
+	Write(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, data map[string]string) (bool, error)
+	// Delete removes the credentials of the DatabaseAccess. It returns false if there was nothing to delete.
+	Delete(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (bool, error)
+	// Applied returns the annotations recording the sink on the DatabaseAccess.
+	Applied(databaseAccess *databasev1alpha1.DatabaseAccess) map[string]string
+	// Location describes where the credentials of the DatabaseAccess are stored, e.g. "Secret database-sample".
+	Location(databaseAccess *databasev1alpha1.DatabaseAccess) string
+}
+
+// credentialSink returns the sink selected by the DatabaseAccessClass, the credentials Secret by default.
+func (r *Reconciler) credentialSink(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (CredentialSink, error) {
+	switch sink := databaseAccessClass.Annotations[CredentialSinkAnnotation]; sink {
+	case "", CredentialSinkSecret:
+		return &secretSink{Client: r.Client, Recorder: r.Recorder}, nil
+	case CredentialSinkVault:
+		return newVaultSink(r.Vault, databaseAccessClass)
+	default:
+		return nil, fmt.Errorf("unsupported %s annotation %q, must be one of %v", CredentialSinkAnnotation, sink, CredentialSinks)
+	}
+}
+
+// appliedCredentialSink returns the sink recorded on the DatabaseAccess, or the sink of the DatabaseAccessClass
+// if the credentials were not written yet. The sink annotations of a DatabaseAccessClass are immutable, so the
+// Vault location of the class is the one the credentials were written to.
+func (r *Reconciler) appliedCredentialSink(databaseAccessClass *databasev1alpha1.DatabaseAccessClass, databaseAccess *databasev1alpha1.DatabaseAccess) (CredentialSink, error) {
+	switch sink := databaseAccess.Annotations[AppliedCredentialSinkAnnotation]; sink {
+	case "":
+		return r.credentialSink(databaseAccessClass)
+	case CredentialSinkSecret:
+		return &secretSink{Client: r.Client, Recorder: r.Recorder}, nil
+	case CredentialSinkVault:
+		if databaseAccessClass.Name == "" {
+			return nil, errVaultClassGone
+		}
+		return newVaultSink(r.Vault, databaseAccessClass)
+	default:
+		return nil, fmt.Errorf("unsupported %s annotation %q, must be one of %v", AppliedCredentialSinkAnnotation, sink, CredentialSinks)
+	}
+}
+
+// errVaultClassGone is returned for credentials written to Vault when the DatabaseAccessClass is gone. Their location
+// can't be taken from the DatabaseAccess, which its owner could point at the credentials of others.
+var errVaultClassGone = errors.New("the DatabaseAccessClass holding the Vault location of the credentials is gone")
+
+// writeCredentials records the sink on the DatabaseAccess and writes the credentials to it. The sink is recorded
+// first, so credentials are never written to a sink the DatabaseAccess does not know about.
+func (r *Reconciler) writeCredentials(ctx context.Context, sink CredentialSink, databaseAccess *databasev1alpha1.DatabaseAccess, data map[string]string) (bool, error) {
+	applied := sink.Applied(databaseAccess)
+	for key, value := range applied {
+		if databaseAccess.Annotations[key] != value {
+			if err := r.patchAnnotations(ctx, databaseAccess, applied); err != nil {
+				return false, err
+			}
+			break
+		}
+	}
+
+	return sink.Write(ctx, databaseAccess, data)
+}
+
+// validateCredentialSink checks the sink annotations of a DatabaseAccessClass.
+func validateCredentialSink(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) error {
+	switch sink := databaseAccessClass.Annotations[CredentialSinkAnnotation]; sink {
+	case "", CredentialSinkSecret:
+		return nil
+	case CredentialSinkVault:
+		_, _, err := vaultPaths(databaseAccessClass)
+		return err
+	default:
+		return fmt.Errorf("unsupported %s annotation %q, must be one of %v", CredentialSinkAnnotation, sink, CredentialSinks)
+	}
+}
+
+// secretSink writes the credentials to the Secret named by the DatabaseAccess, in its namespace.
+type secretSink struct {
+	client.Client
+	Recorder record.EventRecorder
+}
+
+var _ CredentialSink = &secretSink{}
+
+func (s *secretSink) Write(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, data map[string]string) (bool, error) {
+	credentialSecret := &corev1.Secret{}
+	if err := s.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.CredentialsSecretName, Namespace: databaseAccess.Namespace}, credentialSecret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+
+		credentialSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       databaseAccess.Spec.CredentialsSecretName,
+				Namespace:  databaseAccess.Namespace,
+				Finalizers: []string{SecretFinalizer},
+			},
+			StringData: data,
+			Type:       corev1.SecretTypeOpaque,
+		}
+		if err := s.Create(ctx, credentialSecret); err != nil {
+			return false, err
+		}
+		s.Recorder.Eventf(credentialSecret, corev1.EventTypeNormal, ReasonSecretCreated, "Created for DatabaseAccess %s", databaseAccess.Name)
+		return true, nil
+	}
+
+	credentialSecret.Data = nil
+	credentialSecret.StringData = data
+	if err := s.Update(ctx, credentialSecret); err != nil {
+		return false, err
+	}
+	s.Recorder.Eventf(credentialSecret, corev1.EventTypeNormal, ReasonSecretUpdated, "Updated for DatabaseAccess %s", databaseAccess.Name)
+
+	return false, nil
+}
+
+func (s *secretSink) Delete(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (bool, error) {
+	credentialSecret := &corev1.Secret{}
+	if err := s.Get(ctx, client.ObjectKey{Name: databaseAccess.Spec.CredentialsSecretName, Namespace: databaseAccess.Namespace}, credentialSecret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if err := s.Client.Delete(ctx, credentialSecret); err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if err := kubernetes.TryRemoveFinalizer(ctx, s.Client, credentialSecret, SecretFinalizer); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *secretSink) Applied(*databasev1alpha1.DatabaseAccess) map[string]string {
+	return map[string]string{AppliedCredentialSinkAnnotation: CredentialSinkSecret}
+}
+
+func (s *secretSink) Location(databaseAccess *databasev1alpha1.DatabaseAccess) string {
+	return fmt.Sprintf("Secret %s", databaseAccess.Spec.CredentialsSecretName)
+}
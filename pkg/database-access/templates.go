@@ -0,0 +1,112 @@
+package databaseaccess
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// SecretTemplatesAnnotation holds a YAML map of additional Secret keys to Go templates on the DatabaseAccessClass.
+// The templates are rendered with the credential fields returned by the driver, e.g.
+//
+//	DATABASE_URL: postgres://{{ urlUserInfo .username .password }}@{{ .host }}:{{ .port }}/{{ .database }}
+const SecretTemplatesAnnotation = "database.plural.sh/secret-templates"
+
+var templateFuncs = template.FuncMap{
+	"urlQueryEscape": url.QueryEscape,
+	"urlPathEscape":  url.PathEscape,
+	"urlUserInfo": func(username, password string) string {
+		return url.UserPassword(username, password).String()
+	},
+	"pgpassEscape": func(value string) string {
+		return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(value)
+	},
+	"b64enc": func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	},
+	"b64dec": func(value string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		return string(decoded), err
+	},
+	"default": func(defaultValue string, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return defaultValue
+		}
+		return value
+	},
+	"required": func(message string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, errors.New(message)
+		}
+		return value, nil
+	},
+}
+
+// secretTemplates parses the templates defined on the DatabaseAccessClass.
+func secretTemplates(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (map[string]*template.Template, error) {
+	value, ok := databaseAccessClass.Annotations[SecretTemplatesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	definitions := map[string]string{}
+	if err := yaml.Unmarshal([]byte(value), &definitions); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", SecretTemplatesAnnotation, err)
+	}
+
+	templates := make(map[string]*template.Template, len(definitions))
+	for key, definition := range definitions {
+		tmpl, err := template.New(key).Funcs(templateFuncs).Option("missingkey=error").Parse(definition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret template %q: %w", key, err)
+		}
+		templates[key] = tmpl
+	}
+
+	return templates, nil
+}
+
+// renderSecretData returns the credentials extended with the rendered templates.
+func renderSecretData(templates map[string]*template.Template, credentials map[string]string) (map[string]string, error) {
+	data := make(map[string]string, len(credentials)+len(templates))
+	for key, value := range credentials {
+		data[key] = value
+	}
+
+	for key, tmpl := range templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, credentials); err != nil {
+			return nil, fmt.Errorf("failed to render secret template %q: %w", key, err)
+		}
+		data[key] = buf.String()
+	}
+
+	return data, nil
+}
+
+// ValidateDatabaseAccessClass checks the sidecar annotations of a DatabaseAccessClass.
+func ValidateDatabaseAccessClass(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) error {
+	if _, err := secretTemplates(databaseAccessClass); err != nil {
+		return err
+	}
+	if _, err := getRotationPolicy(databaseAccessClass, &databasev1alpha1.DatabaseAccess{}); err != nil {
+		return err
+	}
+	if _, _, err := allowedPrivileges(databaseAccessClass); err != nil {
+		return err
+	}
+	if _, err := getMaxTTL(databaseAccessClass); err != nil {
+		return err
+	}
+	if err := validateCredentialSink(databaseAccessClass); err != nil {
+		return err
+	}
+	return nil
+}
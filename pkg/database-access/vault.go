@@ -0,0 +1,191 @@
+package databaseaccess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+)
+
+const (
+	// VaultMountAnnotation is the mount path of the KV v2 secrets engine the credentials are written to.
+	VaultMountAnnotation = "database.plural.sh/vault-mount"
+	// VaultPathPrefixAnnotation is prepended to the <namespace>/<credentialsSecretName> path of the credentials.
+	VaultPathPrefixAnnotation = "database.plural.sh/vault-path-prefix"
+
+	defaultVaultMount      = "secret"
+	defaultVaultPathPrefix = "database-access"
+	defaultVaultTimeout    = 30 * time.Second
+)
+
+// VaultConfig configures the connection of the Vault credential sink.
+type VaultConfig struct {
+	// Address of the Vault server, e.g. https://vault.example:8200.
+	Address string
+	// TokenFile holds the Vault token. It is read on every request so a renewed token is picked up,
+	// requests are sent without a token when it is empty, e.g. to a Vault agent.
+	TokenFile string
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+}
+
+// vaultSink writes the credentials to a HashiCorp Vault KV v2 secrets engine.
+type vaultSink struct {
+	config     VaultConfig
+	mount      string
+	pathPrefix string
+}
+
+var _ CredentialSink = &vaultSink{}
+
+func newVaultSink(config VaultConfig, databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (*vaultSink, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("DatabaseAccessClass %s writes credentials to Vault but the sidecar has no Vault address", databaseAccessClass.Name)
+	}
+	mount, pathPrefix, err := vaultPaths(databaseAccessClass)
+	if err != nil {
+		return nil, err
+	}
+	return &vaultSink{config: config.withDefaults(), mount: mount, pathPrefix: pathPrefix}, nil
+}
+
+func (c VaultConfig) withDefaults() VaultConfig {
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: defaultVaultTimeout}
+	}
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	return c
+}
+
+// vaultPaths returns the mount and path prefix configured on the DatabaseAccessClass.
+func vaultPaths(databaseAccessClass *databasev1alpha1.DatabaseAccessClass) (string, string, error) {
+	mount, pathPrefix := defaultVaultMount, defaultVaultPathPrefix
+	if value, ok := databaseAccessClass.Annotations[VaultMountAnnotation]; ok {
+		mount = strings.Trim(value, "/")
+		if mount == "" {
+			return "", "", fmt.Errorf("invalid %s annotation: must not be empty", VaultMountAnnotation)
+		}
+	}
+	if value, ok := databaseAccessClass.Annotations[VaultPathPrefixAnnotation]; ok {
+		pathPrefix = strings.Trim(value, "/")
+	}
+	if err := validateVaultPath(VaultMountAnnotation, mount); err != nil {
+		return "", "", err
+	}
+	if err := validateVaultPath(VaultPathPrefixAnnotation, pathPrefix); err != nil {
+		return "", "", err
+	}
+
+	return mount, pathPrefix, nil
+}
+
+// validateVaultPath rejects relative and non-canonical paths, an empty path is valid.
+func validateVaultPath(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(value, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid %s annotation: %q is not a valid path", key, value)
+		}
+	}
+	return nil
+}
+
+func (s *vaultSink) Write(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, data map[string]string) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return false, err
+	}
+
+	var rsp struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, http.MethodPost, s.url("data", databaseAccess), body, &rsp); err != nil {
+		return false, err
+	}
+
+	return rsp.Data.Version == 1, nil
+}
+
+// Delete removes the metadata and every version of the credentials, Vault reports success for missing secrets as well.
+func (s *vaultSink) Delete(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess) (bool, error) {
+	if err := s.do(ctx, http.MethodDelete, s.url("metadata", databaseAccess), nil, nil); err != nil {
+		if errors.Is(err, errVaultNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *vaultSink) Applied(*databasev1alpha1.DatabaseAccess) map[string]string {
+	return map[string]string{AppliedCredentialSinkAnnotation: CredentialSinkVault}
+}
+
+func (s *vaultSink) Location(databaseAccess *databasev1alpha1.DatabaseAccess) string {
+	return fmt.Sprintf("Vault secret %s", path.Join(s.mount, s.secretPath(databaseAccess)))
+}
+
+func (s *vaultSink) secretPath(databaseAccess *databasev1alpha1.DatabaseAccess) string {
+	return path.Join(s.pathPrefix, databaseAccess.Namespace, databaseAccess.Spec.CredentialsSecretName)
+}
+
+// url returns the URL of the credentials below the given KV v2 endpoint, "data" or "metadata".
+func (s *vaultSink) url(endpoint string, databaseAccess *databasev1alpha1.DatabaseAccess) string {
+	return s.config.Address + "/v1/" + path.Join(s.mount, endpoint, s.secretPath(databaseAccess))
+}
+
+var errVaultNotFound = errors.New("not found")
+
+// do sends a request to Vault and decodes the JSON response into out if set.
+func (s *vaultSink) do(ctx context.Context, method, url string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.config.TokenFile != "" {
+		token, err := os.ReadFile(s.config.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Vault token: %w", err)
+		}
+		req.Header.Set("X-Vault-Token", strings.TrimSpace(string(token)))
+	}
+
+	rsp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("Vault %s %s: %w", method, req.URL.Path, errVaultNotFound)
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(rsp.Body, 1<<16)).Decode(&vaultErr)
+		return fmt.Errorf("Vault %s %s returned %s: %s", method, req.URL.Path, rsp.Status, strings.Join(vaultErr.Errors, ", "))
+	}
+	if out == nil || rsp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
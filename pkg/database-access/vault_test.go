@@ -0,0 +1,273 @@
+package databaseaccess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeVault implements the KV v2 data and metadata endpoints of a single mount.
+type fakeVault struct {
+	mount string
+	token string
+
+	mu       sync.Mutex
+	secrets  map[string]map[string]string
+	versions map[string]int
+	requests []string
+	// status makes every request fail with the given status code when set.
+	status int
+}
+
+func newFakeVault(t *testing.T, mount, token string) (*fakeVault, *httptest.Server) {
+	vault := &fakeVault{mount: mount, token: token, secrets: map[string]map[string]string{}, versions: map[string]int{}}
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	return vault, server
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.requests = append(v.requests, r.Method+" "+r.URL.Path)
+
+	if v.status != 0 {
+		w.WriteHeader(v.status)
+		_, _ = w.Write([]byte(`{"errors":["internal error"]}`))
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != v.token {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	dataPrefix, metadataPrefix := "/v1/"+v.mount+"/data/", "/v1/"+v.mount+"/metadata/"
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, dataPrefix):
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, dataPrefix)
+		v.secrets[key] = body.Data
+		v.versions[key]++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": v.versions[key]}})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, metadataPrefix):
+		key := strings.TrimPrefix(r.URL.Path, metadataPrefix)
+		if _, ok := v.secrets[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(v.secrets, key)
+		delete(v.versions, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeToken(t *testing.T, file, token string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(token+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testVaultAccess() *databasev1alpha1.DatabaseAccess {
+	return &databasev1alpha1.DatabaseAccess{
+		ObjectMeta: metav1.ObjectMeta{Name: "access", Namespace: "payments"},
+		Spec:       databasev1alpha1.DatabaseAccessSpec{CredentialsSecretName: "postgres"},
+	}
+}
+
+func testVaultClass(annotations map[string]string) *databasev1alpha1.DatabaseAccessClass {
+	return &databasev1alpha1.DatabaseAccessClass{ObjectMeta: metav1.ObjectMeta{Name: "vault", Annotations: annotations}}
+}
+
+func TestVaultSinkWriteAndDelete(t *testing.T) {
+	vault, server := newFakeVault(t, "kv", "s.token")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeToken(t, tokenFile, "s.token")
+
+	sink, err := newVaultSink(VaultConfig{Address: server.URL + "/", TokenFile: tokenFile}, testVaultClass(map[string]string{
+		CredentialSinkAnnotation:  CredentialSinkVault,
+		VaultMountAnnotation:      "/kv/",
+		VaultPathPrefixAnnotation: "apps/databases",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	databaseAccess := testVaultAccess()
+	key := "apps/databases/payments/postgres"
+
+	created, err := sink.Write(ctx, databaseAccess, map[string]string{"password": "first"})
+	if err != nil || !created {
+		t.Fatalf("first write: created %v, err %v", created, err)
+	}
+	created, err = sink.Write(ctx, databaseAccess, map[string]string{"password": "second"})
+	if err != nil || created {
+		t.Fatalf("second write: created %v, err %v", created, err)
+	}
+	if got := vault.secrets[key]["password"]; got != "second" {
+		t.Errorf("stored password %q, want %q", got, "second")
+	}
+	if got, want := sink.Location(databaseAccess), "Vault secret kv/"+key; got != want {
+		t.Errorf("location %q, want %q", got, want)
+	}
+
+	deleted, err := sink.Delete(ctx, databaseAccess)
+	if err != nil || !deleted {
+		t.Fatalf("delete: deleted %v, err %v", deleted, err)
+	}
+	if _, ok := vault.secrets[key]; ok {
+		t.Error("secret still exists after delete")
+	}
+	// Vault answers 404 for secrets that are already gone.
+	deleted, err = sink.Delete(ctx, databaseAccess)
+	if err != nil || deleted {
+		t.Fatalf("delete of missing secret: deleted %v, err %v", deleted, err)
+	}
+
+	want := []string{
+		"POST /v1/kv/data/" + key,
+		"POST /v1/kv/data/" + key,
+		"DELETE /v1/kv/metadata/" + key,
+		"DELETE /v1/kv/metadata/" + key,
+	}
+	if strings.Join(vault.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests %v, want %v", vault.requests, want)
+	}
+}
+
+func TestVaultSinkTokenFile(t *testing.T) {
+	_, server := newFakeVault(t, "secret", "s.renewed")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeToken(t, tokenFile, "s.expired")
+
+	sink, err := newVaultSink(VaultConfig{Address: server.URL, TokenFile: tokenFile}, testVaultClass(map[string]string{CredentialSinkAnnotation: CredentialSinkVault}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	_, err = sink.Write(ctx, testVaultAccess(), map[string]string{"password": "secret"})
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("write with expired token: got error %v", err)
+	}
+
+	// The token is read again on every request.
+	writeToken(t, tokenFile, "s.renewed")
+	if _, err := sink.Write(ctx, testVaultAccess(), map[string]string{"password": "secret"}); err != nil {
+		t.Fatalf("write with renewed token: %v", err)
+	}
+
+	if err := os.Remove(tokenFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sink.Write(ctx, testVaultAccess(), map[string]string{"password": "secret"}); err == nil || !strings.Contains(err.Error(), "failed to read Vault token") {
+		t.Fatalf("write without token file: got error %v", err)
+	}
+}
+
+func TestVaultSinkErrors(t *testing.T) {
+	vault, server := newFakeVault(t, "secret", "")
+	vault.status = http.StatusInternalServerError
+
+	sink, err := newVaultSink(VaultConfig{Address: server.URL}, testVaultClass(map[string]string{CredentialSinkAnnotation: CredentialSinkVault}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := sink.Write(ctx, testVaultAccess(), map[string]string{"password": "secret"}); err == nil || !strings.Contains(err.Error(), "500") || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("write: got error %v", err)
+	}
+	if deleted, err := sink.Delete(ctx, testVaultAccess()); err == nil || deleted {
+		t.Errorf("delete: deleted %v, err %v", deleted, err)
+	}
+}
+
+func TestVaultSinkConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{name: "defaults", address: "http://vault:8200", annotations: map[string]string{}},
+		{name: "no address", annotations: map[string]string{}, wantErr: true},
+		{name: "empty mount", address: "http://vault:8200", annotations: map[string]string{VaultMountAnnotation: "/"}, wantErr: true},
+		{name: "empty prefix", address: "http://vault:8200", annotations: map[string]string{VaultPathPrefixAnnotation: ""}},
+		{name: "parent prefix", address: "http://vault:8200", annotations: map[string]string{VaultPathPrefixAnnotation: "apps/../other"}, wantErr: true},
+		{name: "empty segment", address: "http://vault:8200", annotations: map[string]string{VaultMountAnnotation: "kv//v2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newVaultSink(VaultConfig{Address: tt.address}, testVaultClass(tt.annotations))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppliedVaultSink(t *testing.T) {
+	vault, server := newFakeVault(t, "kv", "")
+	config := VaultConfig{Address: server.URL}
+	class := testVaultClass(map[string]string{CredentialSinkAnnotation: CredentialSinkVault, VaultMountAnnotation: "kv", VaultPathPrefixAnnotation: "apps"})
+	ctx := context.Background()
+	r := &Reconciler{Vault: config, Recorder: record.NewFakeRecorder(10)}
+
+	// The credentials of another tenant, at the location a forged annotation could point at.
+	vault.secrets["other/payments/postgres"] = map[string]string{"password": "other"}
+
+	sink, err := r.credentialSink(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	databaseAccess := testVaultAccess()
+	if _, err := sink.Write(ctx, databaseAccess, map[string]string{"password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	databaseAccess.Annotations = sink.Applied(databaseAccess)
+	if want := map[string]string{AppliedCredentialSinkAnnotation: CredentialSinkVault}; !reflect.DeepEqual(databaseAccess.Annotations, want) {
+		t.Errorf("applied %v, want %v", databaseAccess.Annotations, want)
+	}
+	// Annotations of earlier versions recorded the location, they are ignored.
+	databaseAccess.Annotations["database.plural.sh/applied-vault-mount"] = "kv"
+	databaseAccess.Annotations["database.plural.sh/applied-vault-path"] = "other/payments/postgres"
+
+	// The class is gone, the location of the credentials is unknown and nothing is deleted.
+	if err := r.deleteCredentials(ctx, &databasev1alpha1.DatabaseAccessClass{}, databaseAccess); err != nil {
+		t.Fatalf("delete without class: %v", err)
+	}
+	if len(vault.secrets) != 2 {
+		t.Errorf("secrets deleted without class: %v", vault.secrets)
+	}
+
+	if err := r.deleteCredentials(ctx, class, databaseAccess); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vault.secrets["apps/payments/postgres"]; ok {
+		t.Error("credentials of the access still exist")
+	}
+	if _, ok := vault.secrets["other/payments/postgres"]; !ok {
+		t.Error("credentials of another tenant were deleted")
+	}
+}
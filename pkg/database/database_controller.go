@@ -15,20 +15,47 @@ import (
 	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
 	databasespec "github.com/pluralsh/database-interface-api/spec"
 	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	DatabaseAccessFinalizer  = "pluralsh.database-interface-controller/databaseaccess-database-protection"
 	DatabaseFinalizer        = "pluralsh.database-interface-controller/database-protection"
 	DatabaseRequestFinalizer = "pluralsh.database-interface-controller/databaserequest-protection"
+
+	// accessRevokePollInterval is the interval a deleted Database checks whether its DatabaseAccesses are revoked.
+	accessRevokePollInterval = 5 * time.Second
+)
+
+const (
+	// ReadyReasonAnnotation, ReadyMessageAnnotation and ReadySeverityAnnotation mirror the Ready
+	// condition of a Database that is not ready on its DatabaseRequest.
+	ReadyReasonAnnotation   = "database.plural.sh/ready-reason"
+	ReadyMessageAnnotation  = "database.plural.sh/ready-message"
+	ReadySeverityAnnotation = "database.plural.sh/ready-severity"
+)
+
+const (
+	// Event reasons recorded by the Database reconciler.
+	ReasonProvisioned        = "Provisioned"
+	ReasonProvisioningFailed = "ProvisioningFailed"
+	ReasonProvisioning       = "Provisioning"
+	ReasonDeleted            = "Deleted"
+	ReasonDeletionFailed     = "DeletionFailed"
+	ReasonAccessDeleted      = "DatabaseAccessDeleted"
+	ReasonFinalizerRemoved   = "FinalizerRemoved"
+	ReasonGracePeriodExpired = "GracePeriodExpired"
 )
 
 // Reconciler reconciles a DatabaseRequest object
@@ -36,8 +63,13 @@ type Reconciler struct {
 	client.Client
 	Log logr.Logger
 
+	Recorder record.EventRecorder
+
 	DriverName        string
 	ProvisionerClient databasespec.ProvisionerClient
+
+	// ProvisioningTimeout is the time the driver may take to provision a database, 0 disables the timeout.
+	ProvisioningTimeout time.Duration
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -59,23 +91,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	if !database.GetDeletionTimestamp().IsZero() {
 		if controllerutil.ContainsFinalizer(database, DatabaseAccessFinalizer) {
-			databaseReqNs := database.Spec.DatabaseRequest.Namespace
-			databaseReqName := database.Spec.DatabaseRequest.Name
-
-			var databaseAccessList databasev1alpha1.DatabaseAccessList
-			if err := r.List(ctx, &databaseAccessList, client.InNamespace(databaseReqNs)); err != nil {
-				log.Error(err, "Failed to get DatabaseAccessList")
+			remaining, err := r.deleteDatabaseAccesses(ctx, database)
+			if err != nil {
+				log.Error(err, "Failed to delete DatabaseAccesses")
 				return ctrl.Result{}, err
 			}
-			for _, databaseAccess := range databaseAccessList.Items {
-				if strings.EqualFold(databaseAccess.Spec.DatabaseRequestName, databaseReqName) {
-					if err := r.Delete(ctx, &databasev1alpha1.DatabaseAccess{
-						ObjectMeta: metav1.ObjectMeta{Name: databaseAccess.Name, Namespace: databaseReqNs},
-					}); err != nil {
-						log.Error(err, "Failed to delete DatabaseAccess")
-						return ctrl.Result{}, err
-					}
-				}
+			// Retained databases outlive the Database, their accounts must be revoked while the driver still knows them.
+			if remaining > 0 {
+				log.Info("Waiting for DatabaseAccesses to be revoked", "remaining", remaining)
+				return ctrl.Result{RequeueAfter: accessRevokePollInterval}, nil
 			}
 			if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, database, DatabaseAccessFinalizer); err != nil {
 				return ctrl.Result{}, err
@@ -84,6 +108,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		if controllerutil.ContainsFinalizer(database, DatabaseFinalizer) {
 			if err := r.deleteDatabaseOp(ctx, database); err != nil {
 				log.Error(err, "Failed to delete Database")
+				r.Recorder.Eventf(database, corev1.EventTypeWarning, ReasonDeletionFailed, "Failed to delete database: %v", err)
+				if err := kubernetes.SetPhase(ctx, r.Client, database, kubernetes.DatabaseFailed); err != nil {
+					log.Error(err, "Failed to set Database phase")
+				}
 				return ctrl.Result{}, err
 			}
 		}
@@ -94,7 +122,28 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, nil
 	}
 
+	if kubernetes.GetPhase(database) == kubernetes.DatabasePendingDeletion {
+		return r.reconcilePendingDeletion(ctx, database)
+	}
+
+	// Statically created Databases wait for a DatabaseRequest to bind them.
+	if database.Labels[kubernetes.PhaseLabel] == "" && database.Spec.DatabaseRequest == nil {
+		if err := kubernetes.SetPhase(ctx, r.Client, database, kubernetes.DatabasePending); err != nil {
+			log.Error(err, "Failed to set Database phase")
+			return ctrl.Result{}, err
+		}
+	}
+
 	if database.Status.Ready {
+		if err := r.syncDatabaseRequest(ctx, database); err != nil {
+			log.Error(err, "Failed to update DatabaseRequest status")
+			return ctrl.Result{}, err
+		}
+		return r.reconcileParameters(ctx, patchHelper, database)
+	}
+
+	// A timed out Database is retried once its Failed phase label is removed.
+	if conditions.GetReason(database, ProvisioningCondition) == ProvisioningTimedOutReason && kubernetes.GetPhase(database) == kubernetes.DatabaseFailed {
 		return ctrl.Result{}, nil
 	}
 
@@ -103,64 +152,41 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	if database.Spec.ExistingDatabaseID == "" {
 		req := &databasespec.DriverCreateDatabaseRequest{
-			Parameters: database.Spec.Parameters,
+			Parameters: createParameters(database),
 			Name:       database.ObjectMeta.Name,
 		}
 		rsp, err := r.ProvisionerClient.DriverCreateDatabase(ctx, req)
+		// Errors, including Unavailable from a driver that is down, are retried with backoff. Only a successful
+		// response without a databaseID means the driver is provisioning, the provisioning timeout is checked then.
 		if err != nil {
 			if status.Code(err) != codes.AlreadyExists {
-				conditions.MarkFalse(database, databasev1alpha1.DatabaseReadyCondition, databasev1alpha1.FailedToCreateDatabaseReason, crhelperTypes.ConditionSeverityError, err.Error())
-				if err := patchDatabase(ctx, patchHelper, database); err != nil {
-					log.Error(err, "failed to patch Database")
-					return ctrl.Result{}, err
-				}
+				r.recordEventf(ctx, database, corev1.EventTypeWarning, ReasonProvisioningFailed, "Driver failed to create database: %v", err)
 				log.Error(err, "Driver failed to create database")
-				return ctrl.Result{}, err
+				return ctrl.Result{}, r.markProvisioningFailed(ctx, patchHelper, database, err)
 			}
 		}
 		if rsp == nil {
 			err = errors.New("DriverCreateDatabase returned a nil response")
 			log.Error(err, "Internal Error from driver")
-			return ctrl.Result{}, err
+			r.recordEventf(ctx, database, corev1.EventTypeWarning, ReasonProvisioningFailed, "Driver returned an invalid response: %v", err)
+			return ctrl.Result{}, r.markProvisioningFailed(ctx, patchHelper, database, err)
 		}
 
 		if rsp.DatabaseId != "" {
 			databaseID = rsp.DatabaseId
 			databaseReady = true
+			metrics.DatabasesProvisioned.WithLabelValues(r.DriverName, database.Spec.DatabaseClassName).Inc()
+			r.recordEventf(ctx, database, corev1.EventTypeNormal, ReasonProvisioned, "Database %s provisioned by driver %s", rsp.DatabaseId, r.DriverName)
 		} else {
-			log.Error(err, "DriverCreateDatabase returned an empty databaseID")
-			err = errors.New("DriverCreateDatabase returned an empty databaseID")
-			return ctrl.Result{}, err
+			// An empty databaseID means the driver is still provisioning the database.
+			return r.markProvisioning(ctx, patchHelper, database, "driver returned no databaseID yet")
 		}
+		conditions.Delete(database, ProvisioningCondition)
 		conditions.MarkTrue(database, databasev1alpha1.DatabaseReadyCondition)
-
-		// Now we update the DatabaseReady status of DatabaseRequest
-		if database.Spec.DatabaseRequest != nil {
-			ref := database.Spec.DatabaseRequest
-
-			databaseReq := &databasev1alpha1.DatabaseRequest{}
-			if err := r.Get(ctx, client.ObjectKey{
-				Namespace: ref.Namespace,
-				Name:      ref.Name,
-			}, databaseReq); err != nil {
-				log.Error(err, "Failed to get database request")
-				return ctrl.Result{}, err
-			}
-
-			databaseReq.Status.Ready = true
-			databaseReq.Status.DatabaseName = database.Name
-			if err := r.Status().Update(ctx, databaseReq); err != nil {
-				if strings.Contains(err.Error(), genericregistry.OptimisticLockErrorMsg) {
-					return reconcile.Result{RequeueAfter: time.Second * 1}, nil
-				}
-				log.Error(err, "Failed to update DatabaseRequest status")
-				return ctrl.Result{}, err
-			}
-			log.Info("Successfully updated status of DatabaseRequest")
-		}
 	} else {
 		databaseReady = true
 		databaseID = database.Spec.ExistingDatabaseID
+		conditions.MarkTrue(database, databasev1alpha1.DatabaseReadyCondition)
 	}
 
 	database.Status.Ready = databaseReady
@@ -174,6 +200,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Error(err, "Can't update finalizer")
 		return ctrl.Result{}, err
 	}
+	setLastAppliedParameters(database)
 
 	if err := patchDatabase(ctx, patchHelper, database); err != nil {
 		if strings.Contains(err.Error(), genericregistry.OptimisticLockErrorMsg) {
@@ -183,9 +210,102 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// Now we update the DatabaseReady status of DatabaseRequest
+	if err := r.syncDatabaseRequest(ctx, database); err != nil {
+		if strings.Contains(err.Error(), genericregistry.OptimisticLockErrorMsg) {
+			return reconcile.Result{RequeueAfter: time.Second * 1}, nil
+		}
+		log.Error(err, "Failed to update DatabaseRequest status")
+		return ctrl.Result{}, err
+	}
+	log.Info("Successfully updated status of DatabaseRequest")
+
 	return ctrl.Result{}, nil
 }
 
+// createParameters returns the parameters passed to the driver to create the database,
+// including the database or snapshot the Database is cloned from.
+func createParameters(database *databasev1alpha1.Database) map[string]string {
+	parameters := make(map[string]string, len(database.Spec.Parameters))
+	for key, value := range database.Spec.Parameters {
+		parameters[key] = value
+	}
+	for _, key := range kubernetes.DataSourceKeys {
+		if value := database.Annotations[key]; value != "" {
+			parameters[key] = value
+		}
+	}
+	return parameters
+}
+
+// markProvisioningFailed records the driver error in the DatabaseReady condition and mirrors it onto
+// the DatabaseRequest. It returns the driver error so the request gets retried.
+func (r *Reconciler) markProvisioningFailed(ctx context.Context, patchHelper *patch.Helper, database *databasev1alpha1.Database, driverErr error) error {
+	metrics.DatabasesFailed.WithLabelValues(r.DriverName, database.Spec.DatabaseClassName).Inc()
+	conditions.MarkFalse(database, databasev1alpha1.DatabaseReadyCondition, databasev1alpha1.FailedToCreateDatabaseReason, crhelperTypes.ConditionSeverityError, "%s", driverErr.Error())
+	if err := patchDatabase(ctx, patchHelper, database); err != nil {
+		r.Log.Error(err, "failed to patch Database", "Database", database.Name)
+		return err
+	}
+	if err := r.syncDatabaseRequest(ctx, database); err != nil {
+		r.Log.Error(err, "Failed to update DatabaseRequest status", "Database", database.Name)
+		return err
+	}
+
+	return driverErr
+}
+
+// syncDatabaseRequest mirrors the summarized Ready condition of the Database onto the bound DatabaseRequest,
+// so namespace users can see why their database is not ready without reading the cluster-scoped Database.
+// DatabaseRequestStatus has no conditions, the reason, message and severity are kept in annotations.
+func (r *Reconciler) syncDatabaseRequest(ctx context.Context, database *databasev1alpha1.Database) error {
+	databaseRequest, err := r.getDatabaseRequest(ctx, database)
+	if err != nil || databaseRequest == nil {
+		return err
+	}
+
+	mirrored := map[string]string{}
+	if condition := conditions.Get(database, crhelperTypes.ReadyCondition); condition != nil && condition.Status != corev1.ConditionTrue {
+		mirrored[ReadyReasonAnnotation] = condition.Reason
+		mirrored[ReadyMessageAnnotation] = condition.Message
+		mirrored[ReadySeverityAnnotation] = string(condition.Severity)
+	}
+
+	original := databaseRequest.DeepCopy()
+	annotations := databaseRequest.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	changed := false
+	for _, key := range []string{ReadyReasonAnnotation, ReadyMessageAnnotation, ReadySeverityAnnotation} {
+		value, ok := annotations[key]
+		switch {
+		case mirrored[key] == "" && ok:
+			delete(annotations, key)
+			changed = true
+		case mirrored[key] != "" && value != mirrored[key]:
+			annotations[key] = mirrored[key]
+			changed = true
+		}
+	}
+	if changed {
+		databaseRequest.SetAnnotations(annotations)
+		if err := r.Patch(ctx, databaseRequest, client.MergeFrom(original)); err != nil {
+			return err
+		}
+	}
+
+	if databaseRequest.Status.Ready != database.Status.Ready || databaseRequest.Status.DatabaseName != database.Name {
+		databaseRequest.Status.Ready = database.Status.Ready
+		databaseRequest.Status.DatabaseName = database.Name
+		if err := r.Status().Update(ctx, databaseRequest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *Reconciler) deleteDatabaseOp(ctx context.Context, database *databasev1alpha1.Database) error {
 	if !strings.EqualFold(database.Spec.DriverName, r.DriverName) {
 		return nil
@@ -200,25 +320,61 @@ func (r *Reconciler) deleteDatabaseOp(ctx context.Context, database *databasev1a
 				return err
 			}
 		}
+		r.recordEventf(ctx, database, corev1.EventTypeNormal, ReasonDeleted, "Database %s deleted by driver %s", database.Status.DatabaseID, r.DriverName)
 	}
 
-	kubernetes.TryRemoveFinalizer(ctx, r.Client, database, DatabaseFinalizer)
+	if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, database, DatabaseFinalizer); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed finalizer %s", DatabaseFinalizer)
 
-	if database.Spec.DatabaseRequest != nil {
-		ref := database.Spec.DatabaseRequest
-		databaseRequest := &databasev1alpha1.DatabaseRequest{}
-		if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, databaseRequest); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return err
-			}
-			return nil
+	databaseRequest, err := r.getDatabaseRequest(ctx, database)
+	if err != nil {
+		return err
+	}
+	if databaseRequest != nil {
+		if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, databaseRequest, DatabaseRequestFinalizer); err != nil {
+			return err
 		}
-		return kubernetes.TryRemoveFinalizer(ctx, r.Client, databaseRequest, DatabaseRequestFinalizer)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed finalizer %s", DatabaseRequestFinalizer)
 	}
 
 	return nil
 }
 
+// recordEventf records the event on the Database and on the DatabaseRequest it is bound to.
+func (r *Reconciler) recordEventf(ctx context.Context, database *databasev1alpha1.Database, eventType, reason, messageFmt string, args ...interface{}) {
+	r.Recorder.Eventf(database, eventType, reason, messageFmt, args...)
+
+	databaseRequest, err := r.getDatabaseRequest(ctx, database)
+	if err != nil || databaseRequest == nil {
+		return
+	}
+	r.Recorder.Eventf(databaseRequest, eventType, reason, messageFmt, args...)
+}
+
+// getDatabaseRequest returns the DatabaseRequest the Database is bound to. It returns nil when the request
+// does not exist anymore, or the Database was released and the reference points at a former request.
+func (r *Reconciler) getDatabaseRequest(ctx context.Context, database *databasev1alpha1.Database) (*databasev1alpha1.DatabaseRequest, error) {
+	ref := database.Spec.DatabaseRequest
+	if ref == nil || kubernetes.GetPhase(database) == kubernetes.DatabaseReleased {
+		return nil, nil
+	}
+
+	databaseRequest := &databasev1alpha1.DatabaseRequest{}
+	if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, databaseRequest); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !kubernetes.IsBoundTo(database, databaseRequest) {
+		return nil, nil
+	}
+
+	return databaseRequest, nil
+}
+
 func patchDatabase(ctx context.Context, patchHelper *patch.Helper, database *databasev1alpha1.Database) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
 	// A step counter is added to represent progress during the provisioning process (instead we are hiding it during the deletion process).
@@ -237,14 +393,63 @@ func patchDatabase(ctx context.Context, patchHelper *patch.Helper, database *dat
 		patch.WithOwnedConditions{Conditions: []crhelperTypes.ConditionType{
 			crhelperTypes.ReadyCondition,
 			databasev1alpha1.DatabaseReadyCondition,
+			ProvisioningCondition,
+			UpdatingCondition,
 		},
 		},
 	)
 }
 
+// deleteDatabaseAccesses deletes the DatabaseAccesses of the DatabaseRequest bound to the Database, the sidecar revokes
+// their accounts before it removes their finalizers. It returns the number of DatabaseAccesses that still exist.
+func (r *Reconciler) deleteDatabaseAccesses(ctx context.Context, database *databasev1alpha1.Database) (int, error) {
+	// A released Database may have had its request reference cleared by an administrator,
+	// its accesses were revoked when it was released.
+	ref := database.Spec.DatabaseRequest
+	if ref == nil || kubernetes.GetPhase(database) == kubernetes.DatabaseReleased {
+		return 0, nil
+	}
+	// Accesses of a newer request of the same name, bound to another Database, are left alone.
+	databaseRequest := &databasev1alpha1.DatabaseRequest{}
+	if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, databaseRequest); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+	} else if !kubernetes.IsBoundTo(database, databaseRequest) {
+		return 0, nil
+	}
+
+	var databaseAccessList databasev1alpha1.DatabaseAccessList
+	if err := r.List(ctx, &databaseAccessList, client.InNamespace(ref.Namespace)); err != nil {
+		return 0, err
+	}
+	remaining := 0
+	for i := range databaseAccessList.Items {
+		databaseAccess := &databaseAccessList.Items[i]
+		if !strings.EqualFold(databaseAccess.Spec.DatabaseRequestName, ref.Name) {
+			continue
+		}
+		remaining++
+		if !databaseAccess.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, databaseAccess); err != nil {
+			if apierrors.IsNotFound(err) {
+				remaining--
+				continue
+			}
+			return remaining, err
+		}
+		r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonAccessDeleted, "Deleted DatabaseAccess %s/%s", ref.Namespace, databaseAccess.Name)
+	}
+
+	return remaining, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasev1alpha1.Database{}).
+		Watches(&source.Kind{Type: &databasev1alpha1.DatabaseClass{}}, handler.EnqueueRequestsFromMapFunc(r.databasesForClass)).
 		Complete(r)
 }
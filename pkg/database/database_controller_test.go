@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeleteDatabaseAccesses(t *testing.T) {
+	const accessFinalizer = "test/revoke"
+	newDatabase := func(phase kubernetes.DatabasePhase, ref *corev1.ObjectReference) *databasev1alpha1.Database {
+		return &databasev1alpha1.Database{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres-orders", Labels: map[string]string{kubernetes.PhaseLabel: string(phase)}},
+			Spec:       databasev1alpha1.DatabaseSpec{DatabaseRequest: ref},
+		}
+	}
+	newRequest := func(uid types.UID) *databasev1alpha1.DatabaseRequest {
+		return &databasev1alpha1.DatabaseRequest{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "payments", UID: uid}}
+	}
+	ref := &corev1.ObjectReference{Name: "orders", Namespace: "payments", UID: "old"}
+
+	tests := []struct {
+		name          string
+		database      *databasev1alpha1.Database
+		request       *databasev1alpha1.DatabaseRequest
+		wantRemaining int
+		wantDeleted   bool
+	}{
+		{name: "bound request", database: newDatabase(kubernetes.DatabaseBound, ref), request: newRequest("old"), wantRemaining: 1, wantDeleted: true},
+		{name: "request gone", database: newDatabase(kubernetes.DatabasePendingDeletion, ref), wantRemaining: 1, wantDeleted: true},
+		{name: "newer request of the same name", database: newDatabase(kubernetes.DatabasePendingDeletion, ref), request: newRequest("new")},
+		{name: "released", database: newDatabase(kubernetes.DatabaseReleased, ref)},
+		{name: "no request reference", database: newDatabase(kubernetes.DatabaseReleased, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := databasev1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			objects := []client.Object{
+				tt.database,
+				&databasev1alpha1.DatabaseAccess{
+					ObjectMeta: metav1.ObjectMeta{Name: "orders-app", Namespace: "payments", Finalizers: []string{accessFinalizer}},
+					Spec:       databasev1alpha1.DatabaseAccessSpec{DatabaseRequestName: "orders"},
+				},
+				&databasev1alpha1.DatabaseAccess{
+					ObjectMeta: metav1.ObjectMeta{Name: "billing-app", Namespace: "payments"},
+					Spec:       databasev1alpha1.DatabaseAccessSpec{DatabaseRequestName: "billing"},
+				},
+			}
+			if tt.request != nil {
+				objects = append(objects, tt.request)
+			}
+			r := &Reconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				Log:      logr.Discard(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+			ctx := context.Background()
+
+			remaining, err := r.deleteDatabaseAccesses(ctx, tt.database)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remaining != tt.wantRemaining {
+				t.Errorf("remaining %d, want %d", remaining, tt.wantRemaining)
+			}
+			databaseAccess := &databasev1alpha1.DatabaseAccess{}
+			if err := r.Get(ctx, client.ObjectKey{Name: "orders-app", Namespace: "payments"}, databaseAccess); err != nil {
+				t.Fatal(err)
+			}
+			if deleted := !databaseAccess.DeletionTimestamp.IsZero(); deleted != tt.wantDeleted {
+				t.Errorf("DatabaseAccess deleted %v, want %v", deleted, tt.wantDeleted)
+			}
+			if err := r.Get(ctx, client.ObjectKey{Name: "billing-app", Namespace: "payments"}, &databasev1alpha1.DatabaseAccess{}); err != nil {
+				t.Errorf("DatabaseAccess of another request: %v", err)
+			}
+
+			if !tt.wantDeleted {
+				return
+			}
+			// The access is counted until the sidecar revoked its account and removed the finalizer.
+			if remaining, err := r.deleteDatabaseAccesses(ctx, tt.database); err != nil || remaining != 1 {
+				t.Fatalf("while revoking: remaining %d, err %v", remaining, err)
+			}
+			databaseAccess.Finalizers = nil
+			if err := r.Update(ctx, databaseAccess); err != nil && !apierrors.IsNotFound(err) {
+				t.Fatal(err)
+			}
+			if remaining, err := r.deleteDatabaseAccesses(ctx, tt.database); err != nil || remaining != 0 {
+				t.Fatalf("after revoking: remaining %d, err %v", remaining, err)
+			}
+		})
+	}
+}
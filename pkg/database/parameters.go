@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/controller-reconcile-helper/pkg/conditions"
+	"github.com/pluralsh/controller-reconcile-helper/pkg/patch"
+	crhelperTypes "github.com/pluralsh/controller-reconcile-helper/pkg/types"
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// LastAppliedParametersAnnotation holds the JSON encoded parameters the database was provisioned with.
+	LastAppliedParametersAnnotation = "database.plural.sh/last-applied-parameters"
+
+	// UpdatingCondition reports parameter changes that were not applied to the database yet.
+	UpdatingCondition crhelperTypes.ConditionType = "Updating"
+
+	// UpdateNotSupportedReason is used while the parameters of a Database differ from the applied ones.
+	// The driver API has no update call, so the change can't be applied to the existing database.
+	UpdateNotSupportedReason = "UpdateNotSupported"
+)
+
+// reconcileParameters detects parameter changes of a ready Database. Parameter changes of the DatabaseClass
+// are copied to dynamically provisioned Databases first. As the driver API offers no update call the drift
+// is reported in the Updating condition and an event until the parameters match the applied ones again.
+func (r *Reconciler) reconcileParameters(ctx context.Context, patchHelper *patch.Helper, database *databasev1alpha1.Database) (ctrl.Result, error) {
+	log := r.Log.WithValues("Database", database.Name)
+
+	if kubernetes.IsDynamicallyProvisioned(database) {
+		databaseClass := &databasev1alpha1.DatabaseClass{}
+		if err := r.Get(ctx, client.ObjectKey{Name: database.Spec.DatabaseClassName}, databaseClass); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		} else if !equalParameters(databaseClass.Parameters, database.Spec.Parameters) {
+			database.Spec.Parameters = databaseClass.Parameters
+			if err := r.Update(ctx, database); err != nil {
+				log.Error(err, "Failed to update Database parameters from DatabaseClass")
+				return ctrl.Result{}, err
+			}
+			log.Info("Updated Database parameters from DatabaseClass", "DatabaseClass", databaseClass.Name)
+			// The update triggers another reconcile with a fresh patch helper.
+			return ctrl.Result{}, nil
+		}
+	}
+
+	value, ok := database.Annotations[LastAppliedParametersAnnotation]
+	if !ok {
+		// Databases provisioned before the parameters were recorded take their current parameters as applied.
+		setLastAppliedParameters(database)
+		return ctrl.Result{}, patchDatabase(ctx, patchHelper, database)
+	}
+	applied := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &applied); err != nil {
+		log.Error(err, "Invalid last applied parameters, recording the current parameters")
+		setLastAppliedParameters(database)
+		return ctrl.Result{}, patchDatabase(ctx, patchHelper, database)
+	}
+
+	if equalParameters(applied, database.Spec.Parameters) {
+		if conditions.Has(database, UpdatingCondition) {
+			conditions.Delete(database, UpdatingCondition)
+			return ctrl.Result{}, patchDatabase(ctx, patchHelper, database)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	message := fmt.Sprintf("Parameters %s changed, but driver %s can't update an existing database",
+		strings.Join(changedParameters(applied, database.Spec.Parameters), ", "), r.DriverName)
+	if conditions.GetMessage(database, UpdatingCondition) != message {
+		r.recordEventf(ctx, database, corev1.EventTypeWarning, UpdateNotSupportedReason, "%s", message)
+	}
+	conditions.MarkFalse(database, UpdatingCondition, UpdateNotSupportedReason, crhelperTypes.ConditionSeverityWarning, "%s", message)
+
+	return ctrl.Result{}, patchDatabase(ctx, patchHelper, database)
+}
+
+// setLastAppliedParameters records the current parameters of the Database as applied.
+func setLastAppliedParameters(database *databasev1alpha1.Database) {
+	parameters := database.Spec.Parameters
+	if parameters == nil {
+		parameters = map[string]string{}
+	}
+	value, _ := json.Marshal(parameters)
+
+	if database.Annotations == nil {
+		database.Annotations = map[string]string{}
+	}
+	database.Annotations[LastAppliedParametersAnnotation] = string(value)
+}
+
+func equalParameters(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// changedParameters returns the sorted keys that differ between the two parameter sets.
+func changedParameters(applied, desired map[string]string) []string {
+	keys := []string{}
+	for key, value := range desired {
+		if appliedValue, ok := applied[key]; !ok || appliedValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range applied {
+		if _, ok := desired[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// databasesForClass maps a DatabaseClass to the Databases of the driver provisioned from it.
+func (r *Reconciler) databasesForClass(obj client.Object) []reconcile.Request {
+	var databaseList databasev1alpha1.DatabaseList
+	if err := r.List(context.Background(), &databaseList); err != nil {
+		r.Log.Error(err, "Failed to list Databases")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, database := range databaseList.Items {
+		if database.Spec.DatabaseClassName == obj.GetName() && strings.EqualFold(database.Spec.DriverName, r.DriverName) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Name: database.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pluralsh/controller-reconcile-helper/pkg/conditions"
+	"github.com/pluralsh/controller-reconcile-helper/pkg/patch"
+	crhelperTypes "github.com/pluralsh/controller-reconcile-helper/pkg/types"
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ProvisioningCondition is true while the driver is provisioning the database asynchronously.
+	ProvisioningCondition crhelperTypes.ConditionType = "Provisioning"
+
+	// ProvisioningInProgressReason is used while waiting for the driver to return a databaseID.
+	ProvisioningInProgressReason = "ProvisioningInProgress"
+	// ProvisioningTimedOutReason is used when the driver did not provision the database within the provisioning timeout.
+	ProvisioningTimedOutReason = "ProvisioningTimedOut"
+
+	minProvisioningPollInterval = 5 * time.Second
+	maxProvisioningPollInterval = 2 * time.Minute
+)
+
+// markProvisioning records that the driver is still provisioning the database and requeues the Database to poll
+// the driver again. The poll interval grows with the time spent provisioning. Once the provisioning timeout is
+// exceeded the Database is marked as failed and no longer polled.
+func (r *Reconciler) markProvisioning(ctx context.Context, patchHelper *patch.Helper, database *databasev1alpha1.Database, detail string) (ctrl.Result, error) {
+	log := r.Log.WithValues("Database", database.Name)
+
+	if !conditions.IsTrue(database, ProvisioningCondition) {
+		conditions.MarkTrue(database, ProvisioningCondition)
+		r.recordEventf(ctx, database, corev1.EventTypeNormal, ReasonProvisioning, "Waiting for driver %s to provision the database", r.DriverName)
+	}
+	elapsed := time.Since(conditions.GetLastTransitionTime(database, ProvisioningCondition).Time)
+
+	if r.ProvisioningTimeout > 0 && elapsed >= r.ProvisioningTimeout {
+		err := fmt.Errorf("database was not provisioned within %s: %s", r.ProvisioningTimeout, detail)
+		log.Error(err, "Provisioning timed out")
+		metrics.DatabasesFailed.WithLabelValues(r.DriverName, database.Spec.DatabaseClassName).Inc()
+		conditions.MarkFalse(database, ProvisioningCondition, ProvisioningTimedOutReason, crhelperTypes.ConditionSeverityError, "%s", err.Error())
+		conditions.MarkFalse(database, databasev1alpha1.DatabaseReadyCondition, ProvisioningTimedOutReason, crhelperTypes.ConditionSeverityError, "%s", err.Error())
+		r.recordEventf(ctx, database, corev1.EventTypeWarning, ReasonProvisioningFailed, "Driver failed to provision the database: %v", err)
+		if err := patchDatabase(ctx, patchHelper, database); err != nil {
+			log.Error(err, "failed to patch Database")
+			return ctrl.Result{}, err
+		}
+		if err := kubernetes.SetPhase(ctx, r.Client, database, kubernetes.DatabaseFailed); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.syncDatabaseRequest(ctx, database)
+	}
+
+	log.Info("Database is being provisioned", "detail", detail, "elapsed", elapsed.Round(time.Second))
+	conditions.MarkFalse(database, databasev1alpha1.DatabaseReadyCondition, ProvisioningInProgressReason, crhelperTypes.ConditionSeverityInfo,
+		"Waiting for driver %s to provision the database: %s", r.DriverName, detail)
+	if err := patchDatabase(ctx, patchHelper, database); err != nil {
+		log.Error(err, "failed to patch Database")
+		return ctrl.Result{}, err
+	}
+	if err := r.syncDatabaseRequest(ctx, database); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: provisioningPollInterval(elapsed, r.ProvisioningTimeout)}, nil
+}
+
+// provisioningPollInterval backs off from minProvisioningPollInterval to maxProvisioningPollInterval as the
+// provisioning takes longer, without polling past the provisioning timeout.
+func provisioningPollInterval(elapsed, timeout time.Duration) time.Duration {
+	interval := elapsed / 4
+	if interval < minProvisioningPollInterval {
+		interval = minProvisioningPollInterval
+	}
+	if interval > maxProvisioningPollInterval {
+		interval = maxProvisioningPollInterval
+	}
+	if timeout > 0 && elapsed+interval > timeout {
+		interval = timeout - elapsed
+	}
+	return interval
+}
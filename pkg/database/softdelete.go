@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcilePendingDeletion deletes a Database in the PendingDeletion phase once its deletion grace period has passed.
+// Until then a new DatabaseRequest of the same name can bind the Database again.
+func (r *Reconciler) reconcilePendingDeletion(ctx context.Context, database *databasev1alpha1.Database) (ctrl.Result, error) {
+	log := r.Log.WithValues("Database", client.ObjectKeyFromObject(database))
+
+	deleteAfter, err := time.Parse(time.RFC3339, database.Annotations[kubernetes.DeleteAfterAnnotation])
+	if err != nil {
+		err = fmt.Errorf("invalid %s annotation: %w", kubernetes.DeleteAfterAnnotation, err)
+		log.Error(err, "Can't delete Database pending deletion")
+		r.Recorder.Event(database, corev1.EventTypeWarning, ReasonDeletionFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+	if remaining := time.Until(deleteAfter); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	// The precondition fails if the Database was bound again since it was read.
+	resourceVersion := database.ResourceVersion
+	if err := r.Delete(ctx, database, client.Preconditions{ResourceVersion: &resourceVersion}); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to delete Database pending deletion")
+		return ctrl.Result{}, err
+	}
+	log.Info("Deletion grace period expired, deleting Database")
+	r.Recorder.Event(database, corev1.EventTypeNormal, ReasonGracePeriodExpired, "Deletion grace period expired")
+
+	return ctrl.Result{}, nil
+}
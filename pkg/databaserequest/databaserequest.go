@@ -2,7 +2,10 @@ package databaserequest
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	crhelperTypes "github.com/pluralsh/controller-reconcile-helper/pkg/types"
 
@@ -12,19 +15,45 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	DatabaseRequestFinalizer = "pluralsh.database-interface-controller/databaserequest-protection"
 )
 
+const (
+	// Event reasons recorded by the DatabaseRequest reconciler.
+	ReasonDatabaseCreated         = "DatabaseCreated"
+	ReasonDatabaseCreateFailed    = "DatabaseCreateFailed"
+	ReasonDatabaseDeleted         = "DatabaseDeleted"
+	ReasonDatabaseDeleteFailed    = "DatabaseDeleteFailed"
+	ReasonDatabaseClassNotFound   = "DatabaseClassNotFound"
+	ReasonDatabaseBound           = "DatabaseBound"
+	ReasonDatabaseBindFailed      = "DatabaseBindFailed"
+	ReasonDatabaseReleased        = "DatabaseReleased"
+	ReasonDatabaseAccessDeleted   = "DatabaseAccessDeleted"
+	ReasonDatabaseRestored        = "DatabaseRestored"
+	ReasonDatabasePendingDeletion = "DatabasePendingDeletion"
+	ReasonNoMatchingDatabase      = "NoMatchingDatabase"
+	ReasonDataSourceFailed        = "DataSourceFailed"
+	ReasonDataSourceInUse         = "DataSourceInUse"
+	ReasonFinalizerRemoved        = "FinalizerRemoved"
+)
+
 // Reconciler reconciles a DatabaseRequest object
 type Reconciler struct {
 	client.Client
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -38,45 +67,65 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 	if !databaseRequest.DeletionTimestamp.IsZero() {
-		databaseRequestCopy := databaseRequest.DeepCopy()
-		if controllerutil.ContainsFinalizer(databaseRequestCopy, DatabaseRequestFinalizer) {
-			database := &databasev1alpha1.Database{
-				ObjectMeta: metav1.ObjectMeta{Name: databaseRequest.Spec.ExistingDatabaseName},
-			}
-			if err := r.Delete(ctx, database); err != nil {
-				log.Error(err, "Error deleting database", "Database", databaseRequest.Spec.ExistingDatabaseName)
-				return ctrl.Result{}, err
-			}
-			log.Info("Successfully deleted database", "Database", databaseRequest.Spec.ExistingDatabaseName)
-
-			return ctrl.Result{}, kubernetes.TryRemoveFinalizer(ctx, r.Client, databaseRequestCopy, DatabaseRequestFinalizer)
+		if err := r.deleteDatabaseRequestOp(ctx, &databaseRequest); err != nil {
+			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
 	}
 
 	if !databaseRequest.Status.Ready {
+		if _, ok := databaseRequest.Annotations[SelectorAnnotation]; ok && databaseRequest.Spec.ExistingDatabaseName == "" {
+			claimed, err := r.claimDatabase(ctx, &databaseRequest)
+			if err != nil || !claimed {
+				return ctrl.Result{}, err
+			}
+		}
 		if databaseRequest.Spec.ExistingDatabaseName == "" {
 			databaseClassName := databaseRequest.Spec.DatabaseClassName
-			if databaseClassName == "" {
-				return ctrl.Result{}, fmt.Errorf("Cannot find database class with the name specified in the database request")
-			}
-
 			var databaseClass databasev1alpha1.DatabaseClass
-			if err := r.Get(ctx, client.ObjectKey{Name: databaseClassName}, &databaseClass); err != nil {
+			if databaseClassName == "" {
+				defaultClass, err := kubernetes.GetDefaultDatabaseClass(ctx, r.Client)
+				if err != nil {
+					log.Error(err, "Can't get default database class")
+					r.Recorder.Eventf(&databaseRequest, corev1.EventTypeWarning, ReasonDatabaseClassNotFound, "No databaseClassName set and no default DatabaseClass: %v", err)
+					return ctrl.Result{}, err
+				}
+				databaseClass = *defaultClass
+				databaseRequest.Spec.DatabaseClassName = databaseClass.Name
+				log.Info("Using default database class", "databaseClass", databaseClass.Name)
+			} else if err := r.Get(ctx, client.ObjectKey{Name: databaseClassName}, &databaseClass); err != nil {
 				log.Error(err, "Can't get database class", "databaseClass", databaseClassName)
+				r.Recorder.Eventf(&databaseRequest, corev1.EventTypeWarning, ReasonDatabaseClassNotFound, "Can't get DatabaseClass %s: %v", databaseClassName, err)
 				return ctrl.Result{}, err
 			}
 
 			newDatabase := genDatabase(databaseRequest, databaseClass)
-			if err := r.Get(ctx, client.ObjectKey{Name: newDatabase.Name}, &databasev1alpha1.Database{}); err != nil {
-				if !apierrors.IsNotFound(err) {
-					return ctrl.Result{}, nil
+			database, err := r.getBoundDatabase(ctx, &databaseRequest, newDatabase.Name, legacyDatabaseName(databaseRequest, databaseClass))
+			if err != nil {
+				log.Error(err, "Can't get database", "Database", newDatabase.Name)
+				r.Recorder.Eventf(&databaseRequest, corev1.EventTypeWarning, ReasonDatabaseCreateFailed, "Failed to get Database %s: %v", newDatabase.Name, err)
+				return ctrl.Result{}, err
+			}
+			if database != nil {
+				newDatabase = database
+			} else {
+				dataSource, err := r.dataSource(ctx, &databaseRequest, &databaseClass)
+				if err != nil {
+					log.Error(err, "Can't use data source")
+					r.Recorder.Eventf(&databaseRequest, corev1.EventTypeWarning, ReasonDataSourceFailed, "Can't clone database: %v", err)
+					return ctrl.Result{}, err
+				}
+				for key, value := range dataSource {
+					newDatabase.Annotations[key] = value
 				}
 				if err := r.Create(ctx, newDatabase); err != nil {
 					log.Error(err, "Can't create database")
+					r.Recorder.Eventf(&databaseRequest, corev1.EventTypeWarning, ReasonDatabaseCreateFailed, "Failed to create Database %s: %v", newDatabase.Name, err)
 					return ctrl.Result{}, err
 				}
 				log.Info("Successfully created database", "Database", newDatabase.Name)
+				r.Recorder.Eventf(&databaseRequest, corev1.EventTypeNormal, ReasonDatabaseCreated, "Created Database %s", newDatabase.Name)
+				r.Recorder.Eventf(newDatabase, corev1.EventTypeNormal, ReasonDatabaseCreated, "Created for DatabaseRequest %s/%s", databaseRequest.Namespace, databaseRequest.Name)
 			}
 			databaseRequest.Spec.ExistingDatabaseName = newDatabase.Name
 			if err := r.Update(ctx, &databaseRequest); err != nil {
@@ -92,13 +141,287 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 
+	if databaseRequest.Spec.ExistingDatabaseName != "" {
+		if err := r.bindDatabase(ctx, &databaseRequest); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if databaseRequest.Status.Ready {
+		if err := r.releaseDataSource(ctx, &databaseRequest); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// bindDatabase binds the Database named in spec.existingBucketName to the DatabaseRequest. Dynamically provisioned
+// Databases already point back at the request, statically created ones are claimed if they are not bound to another request.
+func (r *Reconciler) bindDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest) error {
+	log := r.Log.WithValues("DatabaseRequest", client.ObjectKeyFromObject(databaseRequest))
+
+	database := &databasev1alpha1.Database{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseRequest.Spec.ExistingDatabaseName}, database); err != nil {
+		log.Error(err, "Can't get database", "Database", databaseRequest.Spec.ExistingDatabaseName)
+		if apierrors.IsNotFound(err) {
+			r.Recorder.Eventf(databaseRequest, corev1.EventTypeWarning, ReasonDatabaseBindFailed, "Database %s does not exist", databaseRequest.Spec.ExistingDatabaseName)
+		}
+		return err
+	}
+	if err := r.adoptLegacyDatabase(ctx, databaseRequest, database); err != nil {
+		log.Error(err, "Can't adopt database", "Database", database.Name)
+		return err
+	}
+	if kubernetes.GetPhase(database) == kubernetes.DatabaseBound && kubernetes.IsBoundTo(database, databaseRequest) &&
+		databaseRequest.Labels[kubernetes.PhaseLabel] == string(kubernetes.DatabaseBound) &&
+		controllerutil.ContainsFinalizer(databaseRequest, DatabaseRequestFinalizer) {
+		return nil
+	}
+
+	// Failed Databases keep their phase until an administrator resolves the failure.
+	if kubernetes.GetPhase(database) == kubernetes.DatabaseFailed {
+		return nil
+	}
+
+	ref := database.Spec.DatabaseRequest
+	switch {
+	case kubernetes.IsRestorableBy(database, databaseRequest):
+		// Updating with the resourceVersion read above fails if the sidecar started deleting the Database meanwhile.
+		database.Spec.DatabaseRequest = &corev1.ObjectReference{
+			Name:      databaseRequest.Name,
+			Namespace: databaseRequest.Namespace,
+			UID:       databaseRequest.UID,
+		}
+		database.Labels[kubernetes.PhaseLabel] = string(kubernetes.DatabaseBound)
+		delete(database.Annotations, kubernetes.DeleteAfterAnnotation)
+		if err := r.Update(ctx, database); err != nil {
+			log.Error(err, "Can't restore database", "Database", database.Name)
+			return err
+		}
+		log.Info("Successfully restored database", "Database", database.Name)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabaseRestored, "Restored Database %s pending deletion", database.Name)
+		r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonDatabaseRestored, "Restored by DatabaseRequest %s/%s", databaseRequest.Namespace, databaseRequest.Name)
+	case kubernetes.GetPhase(database) == kubernetes.DatabaseReleased:
+		err := fmt.Errorf("Database %s was released by its previous DatabaseRequest and can't be bound", database.Name)
+		r.Recorder.Event(databaseRequest, corev1.EventTypeWarning, ReasonDatabaseBindFailed, err.Error())
+		return err
+	case ref == nil || (kubernetes.IsBoundTo(database, databaseRequest) && ref.UID == ""):
+		// Updating with the resourceVersion read above fails on a conflict, so a Database is never claimed twice.
+		database.Spec.DatabaseRequest = &corev1.ObjectReference{
+			Name:      databaseRequest.Name,
+			Namespace: databaseRequest.Namespace,
+			UID:       databaseRequest.UID,
+		}
+		if err := r.Update(ctx, database); err != nil {
+			log.Error(err, "Can't bind database", "Database", database.Name)
+			return err
+		}
+	case !kubernetes.IsBoundTo(database, databaseRequest):
+		err := fmt.Errorf("Database %s is bound to DatabaseRequest %s/%s", database.Name, ref.Namespace, ref.Name)
+		r.Recorder.Event(databaseRequest, corev1.EventTypeWarning, ReasonDatabaseBindFailed, err.Error())
+		return err
+	}
+
+	if err := kubernetes.SetPhase(ctx, r.Client, database, kubernetes.DatabaseBound); err != nil {
+		return err
+	}
+	if err := kubernetes.TryAddFinalizer(ctx, r.Client, databaseRequest, DatabaseRequestFinalizer); err != nil {
+		return err
+	}
+	if err := r.setRequestPhase(ctx, databaseRequest, kubernetes.DatabaseBound, ""); err != nil {
+		return err
+	}
+	log.Info("Successfully bound database", "Database", database.Name)
+	r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabaseBound, "Bound to Database %s", database.Name)
+	r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonDatabaseBound, "Bound to DatabaseRequest %s/%s", databaseRequest.Namespace, databaseRequest.Name)
+
+	return nil
+}
+
+// deleteDatabaseRequestOp deletes the Database of a DatabaseRequest if it was dynamically provisioned or its
+// DeletionPolicy is Delete, otherwise the Database is released and kept.
+func (r *Reconciler) deleteDatabaseRequestOp(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest) error {
+	log := r.Log.WithValues("DatabaseRequest", client.ObjectKeyFromObject(databaseRequest))
+
+	if err := r.releaseDataSource(ctx, databaseRequest); err != nil {
+		return err
+	}
+	if !controllerutil.ContainsFinalizer(databaseRequest, DatabaseRequestFinalizer) {
+		return nil
+	}
+	// Clones of this request still read from its database, it is released once they are ready.
+	if controllerutil.ContainsFinalizer(databaseRequest, CloneSourceFinalizer) {
+		log.Info("Waiting for clones of the database to complete")
+		r.Recorder.Event(databaseRequest, corev1.EventTypeNormal, ReasonDataSourceInUse, "Deletion deferred until clones of the database are ready")
+		return nil
+	}
+	databaseRequestCopy := databaseRequest.DeepCopy()
+
+	database := &databasev1alpha1.Database{}
+	if err := r.Get(ctx, client.ObjectKey{Name: databaseRequest.Spec.ExistingDatabaseName}, database); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+	} else if !kubernetes.IsBoundTo(database, databaseRequest) {
+		// The Database belongs to another request and must not be deleted with this one.
+		log.Info("Skipping deletion of database bound to another request", "Database", database.Name)
+	} else if err := r.adoptLegacyDatabase(ctx, databaseRequest, database); err != nil {
+		log.Error(err, "Can't adopt database", "Database", database.Name)
+		return err
+	} else if kubernetes.IsDynamicallyProvisioned(database) || database.Spec.DeletionPolicy == databasev1alpha1.DeletionPolicyDelete {
+		if err := r.deleteDatabase(ctx, databaseRequest, database); err != nil {
+			return err
+		}
+	} else {
+		// A released Database can be bound by another tenant, the accounts of this request must be gone first.
+		remaining, err := r.deleteDatabaseAccesses(ctx, databaseRequest)
+		if err != nil {
+			log.Error(err, "Error deleting DatabaseAccesses")
+			return err
+		}
+		// The DatabaseAccess watch triggers the release once the last access is gone.
+		if remaining > 0 {
+			log.Info("Waiting for DatabaseAccesses to be revoked before releasing database", "Database", database.Name, "remaining", remaining)
+			return nil
+		}
+		if err := kubernetes.SetPhase(ctx, r.Client, database, kubernetes.DatabaseReleased); err != nil {
+			log.Error(err, "Error releasing database", "Database", database.Name)
+			return err
+		}
+		log.Info("Successfully released database", "Database", database.Name)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabaseReleased, "Released Database %s", database.Name)
+		r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonDatabaseReleased, "Released on removal of DatabaseRequest %s/%s", databaseRequest.Namespace, databaseRequest.Name)
+	}
+
+	if err := kubernetes.TryRemoveFinalizer(ctx, r.Client, databaseRequestCopy, DatabaseRequestFinalizer); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed finalizer %s", DatabaseRequestFinalizer)
+
+	return nil
+}
+
+// deleteDatabaseAccesses deletes the DatabaseAccesses of the DatabaseRequest, the sidecar revokes their accounts
+// before it removes their finalizers. It returns the number of DatabaseAccesses that still exist.
+func (r *Reconciler) deleteDatabaseAccesses(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest) (int, error) {
+	var databaseAccessList databasev1alpha1.DatabaseAccessList
+	if err := r.List(ctx, &databaseAccessList, client.InNamespace(databaseRequest.Namespace)); err != nil {
+		return 0, err
+	}
+
+	remaining := 0
+	for i := range databaseAccessList.Items {
+		databaseAccess := &databaseAccessList.Items[i]
+		if databaseAccess.Spec.DatabaseRequestName != databaseRequest.Name {
+			continue
+		}
+		remaining++
+		if !databaseAccess.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, databaseAccess); err != nil {
+			if apierrors.IsNotFound(err) {
+				remaining--
+				continue
+			}
+			return remaining, err
+		}
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabaseAccessDeleted, "Deleted DatabaseAccess %s", databaseAccess.Name)
+	}
+
+	return remaining, nil
+}
+
+// getBoundDatabase returns the Database with one of the given names that was generated for the DatabaseRequest,
+// or nil if there is none. A Database with a matching name that points back at another request is reported as a conflict.
+func (r *Reconciler) getBoundDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, names ...string) (*databasev1alpha1.Database, error) {
+	for _, name := range names {
+		database := &databasev1alpha1.Database{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, database); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if (kubernetes.IsBoundTo(database, databaseRequest) && kubernetes.GetPhase(database) != kubernetes.DatabaseReleased) ||
+			kubernetes.IsRestorableBy(database, databaseRequest) {
+			if err := r.adoptLegacyDatabase(ctx, databaseRequest, database); err != nil {
+				return nil, err
+			}
+			return database, nil
+		}
+		// Only the current name is reserved for the request, a legacy named Database
+		// may belong to a request of the same name in another namespace.
+		if name == names[0] {
+			ref := database.Spec.DatabaseRequest
+			if ref == nil {
+				return nil, fmt.Errorf("Database %s already exists and is not bound to a DatabaseRequest", name)
+			}
+			return nil, fmt.Errorf("Database %s already exists and is bound to DatabaseRequest %s/%s", name, ref.Namespace, ref.Name)
+		}
+	}
+
+	return nil, nil
+}
+
+const (
+	// maxDatabaseNameLength keeps generated names usable as label values.
+	maxDatabaseNameLength  = validation.DNS1123LabelMaxLength
+	databaseNameHashLength = 10
+)
+
+// databaseName returns the name of the Database generated for a DatabaseRequest. The name is
+// CLASS-REQUEST truncated to a valid length, followed by a hash of the request namespace and name,
+// so requests of the same name in different namespaces don't collide.
+func databaseName(request databasev1alpha1.DatabaseRequest, class databasev1alpha1.DatabaseClass) string {
+	sum := sha256.Sum256([]byte(request.Namespace + "/" + request.Name))
+	hash := hex.EncodeToString(sum[:])[:databaseNameHashLength]
+
+	prefix := fmt.Sprintf("%s-%s", class.Name, request.Name)
+	if maxPrefixLength := maxDatabaseNameLength - databaseNameHashLength - 1; len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-.")
+	}
+	return fmt.Sprintf("%s-%s", prefix, hash)
+}
+
+// adoptLegacyDatabase adds ProvisionedByAnnotation to a Database generated under its legacy name before the annotation
+// was introduced, so it is deleted with its request and follows parameter changes of its class. The name proves the
+// Database was generated for the request, other Databases are left alone.
+func (r *Reconciler) adoptLegacyDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, database *databasev1alpha1.Database) error {
+	className := database.Spec.DatabaseClassName
+	if kubernetes.IsDynamicallyProvisioned(database) || database.Spec.DriverName == "" || className == "" ||
+		className != databaseRequest.Spec.DatabaseClassName || !kubernetes.IsBoundTo(database, databaseRequest) ||
+		database.Name != legacyDatabaseName(*databaseRequest, databasev1alpha1.DatabaseClass{ObjectMeta: metav1.ObjectMeta{Name: className}}) {
+		return nil
+	}
+
+	original := database.DeepCopy()
+	if database.Annotations == nil {
+		database.Annotations = map[string]string{}
+	}
+	database.Annotations[kubernetes.ProvisionedByAnnotation] = database.Spec.DriverName
+	if err := r.Patch(ctx, database, client.MergeFrom(original)); err != nil {
+		return err
+	}
+	r.Log.Info("Adopted legacy database", "Database", database.Name, "DatabaseRequest", client.ObjectKeyFromObject(databaseRequest))
+	return nil
+}
+
+// legacyDatabaseName returns the CLASS-REQUEST name Databases were generated with before the namespace was hashed into it.
+// Such Databases are adopted as long as they point back at the request.
+func legacyDatabaseName(request databasev1alpha1.DatabaseRequest, class databasev1alpha1.DatabaseClass) string {
+	return fmt.Sprintf("%s-%s", class.Name, request.Name)
+}
+
 func genDatabase(request databasev1alpha1.DatabaseRequest, class databasev1alpha1.DatabaseClass) *databasev1alpha1.Database {
-	name := fmt.Sprintf("%s-%s", class.Name, request.Name)
+	name := databaseName(request, class)
 	return &databasev1alpha1.Database{
-		ObjectMeta: metav1.ObjectMeta{Name: name},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{kubernetes.PhaseLabel: string(kubernetes.DatabaseBound)},
+			Annotations: map[string]string{kubernetes.ProvisionedByAnnotation: class.DriverName},
+		},
 		Spec: databasev1alpha1.DatabaseSpec{
 			DriverName:        class.DriverName,
 			DatabaseClassName: class.Name,
@@ -106,6 +429,7 @@ func genDatabase(request databasev1alpha1.DatabaseRequest, class databasev1alpha
 			DatabaseRequest: &corev1.ObjectReference{
 				Name:      request.Name,
 				Namespace: request.Namespace,
+				UID:       request.UID,
 			},
 			DeletionPolicy: class.DeletionPolicy,
 		},
@@ -121,5 +445,16 @@ func genDatabase(request databasev1alpha1.DatabaseRequest, class databasev1alpha
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&databasev1alpha1.DatabaseRequest{}).
+		Watches(&source.Kind{Type: &databasev1alpha1.Database{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForDatabase)).
+		Watches(&source.Kind{Type: &databasev1alpha1.DatabaseAccess{}}, handler.EnqueueRequestsFromMapFunc(requestForDatabaseAccess)).
 		Complete(r)
 }
+
+// requestForDatabaseAccess maps a DatabaseAccess to its DatabaseRequest, which waits for its accesses to be revoked before it is released.
+func requestForDatabaseAccess(obj client.Object) []reconcile.Request {
+	databaseAccess, ok := obj.(*databasev1alpha1.DatabaseAccess)
+	if !ok || databaseAccess.Spec.DatabaseRequestName == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: databaseAccess.Spec.DatabaseRequestName, Namespace: databaseAccess.Namespace}}}
+}
@@ -0,0 +1,266 @@
+package databaserequest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testRequest(namespace, name string) databasev1alpha1.DatabaseRequest {
+	return databasev1alpha1.DatabaseRequest{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+}
+
+func testClass(name string) databasev1alpha1.DatabaseClass {
+	return databasev1alpha1.DatabaseClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func TestDatabaseName(t *testing.T) {
+	long := strings.Repeat("a", validation.DNS1123SubdomainMaxLength)
+	tests := []struct {
+		name       string
+		class      string
+		namespace  string
+		request    string
+		wantPrefix string
+	}{
+		{name: "short", class: "postgres", namespace: "payments", request: "orders", wantPrefix: "postgres-orders-"},
+		{name: "dotted", class: "postgres.example.com", namespace: "payments", request: "orders.v2", wantPrefix: "postgres.example.com-orders.v2-"},
+		{name: "long request", class: "postgres", namespace: "payments", request: long, wantPrefix: "postgres-" + long[:43] + "-"},
+		{name: "long class", class: long, namespace: "payments", request: "orders", wantPrefix: long[:52] + "-"},
+		{name: "truncated at dash", class: strings.Repeat("a", 51), namespace: "payments", request: "orders", wantPrefix: strings.Repeat("a", 51) + "-"},
+		{name: "truncated at dot", class: "postgres", namespace: "payments", request: strings.Repeat("a", 42) + ".orders", wantPrefix: "postgres-" + strings.Repeat("a", 42) + "-"},
+		{name: "truncated at dot and dash", class: "postgres", namespace: "payments", request: strings.Repeat("a", 41) + "-.orders", wantPrefix: "postgres-" + strings.Repeat("a", 41) + "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := databaseName(testRequest(tt.namespace, tt.request), testClass(tt.class))
+			if !strings.HasPrefix(name, tt.wantPrefix) {
+				t.Errorf("name %q, want prefix %q", name, tt.wantPrefix)
+			}
+			if hash := strings.TrimPrefix(name, tt.wantPrefix); len(hash) != databaseNameHashLength {
+				t.Errorf("name %q, want a %d character hash suffix", name, databaseNameHashLength)
+			}
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				t.Errorf("name %q is not a valid object name: %v", name, errs)
+			}
+			if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+				t.Errorf("name %q is not a valid label value: %v", name, errs)
+			}
+			if again := databaseName(testRequest(tt.namespace, tt.request), testClass(tt.class)); again != name {
+				t.Errorf("name %q is not stable, got %q", name, again)
+			}
+		})
+	}
+}
+
+func TestDatabaseNameCollisions(t *testing.T) {
+	long := strings.Repeat("a", validation.DNS1123LabelMaxLength)
+	tests := []struct {
+		name   string
+		first  databasev1alpha1.DatabaseRequest
+		second databasev1alpha1.DatabaseRequest
+	}{
+		{name: "same name in other namespace", first: testRequest("payments", "orders"), second: testRequest("billing", "orders")},
+		{name: "truncated to the same prefix", first: testRequest("payments", long+"-first"), second: testRequest("payments", long+"-second")},
+		{name: "namespace and name shifted", first: testRequest("pay", "ments-orders"), second: testRequest("pay-ments", "orders")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := testClass("postgres")
+			if first, second := databaseName(tt.first, class), databaseName(tt.second, class); first == second {
+				t.Errorf("requests %s/%s and %s/%s both generate Database %s", tt.first.Namespace, tt.first.Name, tt.second.Namespace, tt.second.Name, first)
+			}
+		})
+	}
+
+	// Both requests share the legacy name, only the hashed name tells them apart.
+	class := testClass("postgres")
+	if legacyDatabaseName(testRequest("payments", "orders"), class) != legacyDatabaseName(testRequest("billing", "orders"), class) {
+		t.Error("legacy names differ across namespaces")
+	}
+}
+
+func TestGetBoundDatabase(t *testing.T) {
+	request := testRequest("payments", "orders")
+	request.Spec.DatabaseClassName = "postgres"
+	class := testClass("postgres")
+	current, legacy := databaseName(request, class), legacyDatabaseName(request, class)
+
+	database := func(name string, ref *corev1.ObjectReference) *databasev1alpha1.Database {
+		return &databasev1alpha1.Database{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       databasev1alpha1.DatabaseSpec{DatabaseRequest: ref, DatabaseClassName: "postgres", DriverName: "postgres.database.plural.sh"},
+		}
+	}
+	ownRef := &corev1.ObjectReference{Namespace: "payments", Name: "orders"}
+	otherRef := &corev1.ObjectReference{Namespace: "billing", Name: "orders"}
+	otherClass := database(legacy, ownRef)
+	otherClass.Spec.DatabaseClassName = "mysql"
+
+	tests := []struct {
+		name      string
+		databases []*databasev1alpha1.Database
+		want      string
+		wantErr   bool
+		// wantProvisionedBy is the ProvisionedByAnnotation expected on the returned Database.
+		wantProvisionedBy string
+	}{
+		{name: "none"},
+		{name: "current", databases: []*databasev1alpha1.Database{database(current, ownRef)}, want: current},
+		{name: "legacy adopted", databases: []*databasev1alpha1.Database{database(legacy, ownRef)}, want: legacy, wantProvisionedBy: "postgres.database.plural.sh"},
+		{name: "legacy name of another class", databases: []*databasev1alpha1.Database{otherClass}, want: legacy},
+		{name: "legacy of other namespace", databases: []*databasev1alpha1.Database{database(legacy, otherRef)}},
+		{name: "current bound to other request", databases: []*databasev1alpha1.Database{database(current, otherRef)}, wantErr: true},
+		{name: "current not bound", databases: []*databasev1alpha1.Database{database(current, nil)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := databasev1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			for _, database := range tt.databases {
+				builder = builder.WithObjects(database)
+			}
+			r := &Reconciler{Client: builder.Build(), Log: logr.Discard()}
+
+			got, err := r.getBoundDatabase(context.Background(), &request, current, legacy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			name := ""
+			if got != nil {
+				name = got.Name
+			}
+			if name != tt.want {
+				t.Errorf("got Database %q, want %q", name, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			stored := &databasev1alpha1.Database{}
+			if err := r.Get(context.Background(), client.ObjectKeyFromObject(got), stored); err != nil {
+				t.Fatal(err)
+			}
+			if provisionedBy := stored.Annotations[kubernetes.ProvisionedByAnnotation]; provisionedBy != tt.wantProvisionedBy {
+				t.Errorf("got %s %q, want %q", kubernetes.ProvisionedByAnnotation, provisionedBy, tt.wantProvisionedBy)
+			}
+		})
+	}
+}
+
+func TestReleaseWaitsForDatabaseAccesses(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := databasev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	request := testRequest("payments", "orders")
+	request.UID = "request-uid"
+	request.Finalizers = []string{DatabaseRequestFinalizer}
+	request.Spec.ExistingDatabaseName = "orders"
+	database := &databasev1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Labels: map[string]string{kubernetes.PhaseLabel: string(kubernetes.DatabaseBound)}},
+		Spec: databasev1alpha1.DatabaseSpec{
+			DatabaseRequest: &corev1.ObjectReference{Name: "orders", Namespace: "payments", UID: "request-uid"},
+			DeletionPolicy:  databasev1alpha1.DeletionPolicyRetain,
+		},
+	}
+	databaseAccess := &databasev1alpha1.DatabaseAccess{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "payments", Finalizers: []string{"test/revoke"}},
+		Spec:       databasev1alpha1.DatabaseAccessSpec{DatabaseRequestName: "orders"},
+	}
+	r := &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(&request, database, databaseAccess).Build(),
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(&request)
+	if err := r.Delete(ctx, &request); err != nil {
+		t.Fatal(err)
+	}
+	phase := func() kubernetes.DatabasePhase {
+		t.Helper()
+		database := &databasev1alpha1.Database{}
+		if err := r.Get(ctx, client.ObjectKey{Name: "orders"}, database); err != nil {
+			t.Fatal(err)
+		}
+		return kubernetes.GetPhase(database)
+	}
+
+	// Waiting for the sidecar to revoke the access is not an error.
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("while revoking: %v", err)
+	}
+	if got := phase(); got != kubernetes.DatabaseBound {
+		t.Errorf("phase %s while revoking, want %s", got, kubernetes.DatabaseBound)
+	}
+	if reqs := requestForDatabaseAccess(databaseAccess); len(reqs) != 1 || reqs[0].NamespacedName != key {
+		t.Errorf("DatabaseAccess maps to %v, want %v", reqs, key)
+	}
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(databaseAccess), databaseAccess); err != nil {
+		t.Fatal(err)
+	}
+	databaseAccess.Finalizers = nil
+	if err := r.Update(ctx, databaseAccess); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if got := phase(); got != kubernetes.DatabaseReleased {
+		t.Errorf("phase %s, want %s", got, kubernetes.DatabaseReleased)
+	}
+}
+
+func TestDeleteRequestOfLegacyDatabase(t *testing.T) {
+	// The request was bound to its legacy named Database before ProvisionedByAnnotation was introduced.
+	scheme := runtime.NewScheme()
+	if err := databasev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	request := testRequest("payments", "orders")
+	request.Finalizers = []string{DatabaseRequestFinalizer}
+	request.Spec.DatabaseClassName = "postgres"
+	request.Spec.ExistingDatabaseName = "postgres-orders"
+	database := &databasev1alpha1.Database{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-orders", Labels: map[string]string{kubernetes.PhaseLabel: string(kubernetes.DatabaseBound)}},
+		Spec: databasev1alpha1.DatabaseSpec{
+			DriverName:        "postgres.database.plural.sh",
+			DatabaseClassName: "postgres",
+			DatabaseRequest:   &corev1.ObjectReference{Name: "orders", Namespace: "payments"},
+			DeletionPolicy:    databasev1alpha1.DeletionPolicyRetain,
+		},
+	}
+	r := &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(&request, database).Build(),
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+	ctx := context.Background()
+	if err := r.Delete(ctx, &request); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&request)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(database), database); !apierrors.IsNotFound(err) {
+		t.Errorf("Database %s in phase %s, want it deleted with its request", database.Name, kubernetes.GetPhase(database))
+	}
+}
@@ -0,0 +1,99 @@
+package databaserequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DataSourceAnnotation names a DatabaseRequest in the same namespace whose database is cloned into the new one.
+	DataSourceAnnotation = "database.plural.sh/data-source"
+	// DataSourceSnapshotAnnotation holds the ID of a driver snapshot the new database is restored from.
+	DataSourceSnapshotAnnotation = "database.plural.sh/data-source-snapshot"
+
+	// CloneSourceFinalizer keeps a DatabaseRequest and its Database while a clone of it is in progress.
+	CloneSourceFinalizer = "pluralsh.database-interface-controller/clone-source-protection"
+)
+
+// dataSource returns the annotations describing the data source of the Database generated for the DatabaseRequest.
+// A source DatabaseRequest must be ready and served by the same driver, it is protected from deletion until the clone is ready.
+func (r *Reconciler) dataSource(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, databaseClass *databasev1alpha1.DatabaseClass) (map[string]string, error) {
+	if snapshotID := databaseRequest.Annotations[DataSourceSnapshotAnnotation]; snapshotID != "" {
+		return map[string]string{kubernetes.DataSourceSnapshotIDKey: snapshotID}, nil
+	}
+
+	sourceName := databaseRequest.Annotations[DataSourceAnnotation]
+	if sourceName == "" {
+		return nil, nil
+	}
+	if sourceName == databaseRequest.Name {
+		return nil, fmt.Errorf("DatabaseRequest can't be cloned from itself")
+	}
+
+	source := &databasev1alpha1.DatabaseRequest{}
+	if err := r.Get(ctx, client.ObjectKey{Name: sourceName, Namespace: databaseRequest.Namespace}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("source DatabaseRequest %s does not exist", sourceName)
+		}
+		return nil, err
+	}
+	if !source.DeletionTimestamp.IsZero() {
+		return nil, fmt.Errorf("source DatabaseRequest %s is being deleted", sourceName)
+	}
+	if !source.Status.Ready || source.Spec.ExistingDatabaseName == "" {
+		return nil, fmt.Errorf("source DatabaseRequest %s is not ready yet", sourceName)
+	}
+
+	database := &databasev1alpha1.Database{}
+	if err := r.Get(ctx, client.ObjectKey{Name: source.Spec.ExistingDatabaseName}, database); err != nil {
+		return nil, err
+	}
+	if !kubernetes.IsBoundTo(database, source) || database.Status.DatabaseID == "" {
+		return nil, fmt.Errorf("Database %s of source DatabaseRequest %s is not provisioned", database.Name, sourceName)
+	}
+	if !strings.EqualFold(database.Spec.DriverName, databaseClass.DriverName) {
+		return nil, fmt.Errorf("source DatabaseRequest %s uses driver %s, DatabaseClass %s uses driver %s",
+			sourceName, database.Spec.DriverName, databaseClass.Name, databaseClass.DriverName)
+	}
+
+	if err := kubernetes.TryAddFinalizer(ctx, r.Client, source, CloneSourceFinalizer); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{kubernetes.DataSourceDatabaseIDKey: database.Status.DatabaseID}, nil
+}
+
+// releaseDataSource removes the clone protection from the source of the DatabaseRequest once no clone of it is in progress anymore.
+func (r *Reconciler) releaseDataSource(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest) error {
+	sourceName := databaseRequest.Annotations[DataSourceAnnotation]
+	if sourceName == "" {
+		return nil
+	}
+
+	source := &databasev1alpha1.DatabaseRequest{}
+	if err := r.Get(ctx, client.ObjectKey{Name: sourceName, Namespace: databaseRequest.Namespace}, source); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !controllerutil.ContainsFinalizer(source, CloneSourceFinalizer) {
+		return nil
+	}
+
+	var databaseRequestList databasev1alpha1.DatabaseRequestList
+	if err := r.List(ctx, &databaseRequestList, client.InNamespace(databaseRequest.Namespace)); err != nil {
+		return err
+	}
+	for _, clone := range databaseRequestList.Items {
+		if clone.Annotations[DataSourceAnnotation] == sourceName && clone.DeletionTimestamp.IsZero() && !clone.Status.Ready {
+			return nil
+		}
+	}
+
+	return kubernetes.TryRemoveFinalizer(ctx, r.Client, source, CloneSourceFinalizer)
+}
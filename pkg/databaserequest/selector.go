@@ -0,0 +1,165 @@
+package databaserequest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// SelectorAnnotation holds a label selector, e.g. "team=payments,tier in (gold)". A DatabaseRequest carrying it
+	// binds to an unbound Database matching the selector instead of provisioning a new one.
+	SelectorAnnotation = "database.plural.sh/selector"
+	// PendingReasonAnnotation explains why a DatabaseRequest is still Pending.
+	PendingReasonAnnotation = "database.plural.sh/pending-reason"
+)
+
+// claimDatabase binds the DatabaseRequest to an unbound Database matching its selector. It returns false
+// when no Database matches, in which case the request is marked Pending until a matching Database shows up.
+func (r *Reconciler) claimDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest) (bool, error) {
+	log := r.Log.WithValues("DatabaseRequest", client.ObjectKeyFromObject(databaseRequest))
+
+	selector, err := labels.Parse(databaseRequest.Annotations[SelectorAnnotation])
+	if err != nil {
+		// Retrying won't help, the request is reconciled again once the annotation is fixed.
+		message := fmt.Sprintf("Invalid %s annotation: %v", SelectorAnnotation, err)
+		r.Recorder.Event(databaseRequest, corev1.EventTypeWarning, ReasonNoMatchingDatabase, message)
+		return false, r.setRequestPhase(ctx, databaseRequest, kubernetes.DatabasePending, message)
+	}
+
+	database, err := r.selectDatabase(ctx, databaseRequest, selector)
+	if err != nil {
+		return false, err
+	}
+	if database == nil {
+		message := fmt.Sprintf("No unbound Database matches selector %q", selector.String())
+		if databaseRequest.Spec.DatabaseClassName != "" {
+			message = fmt.Sprintf("%s and class %s", message, databaseRequest.Spec.DatabaseClassName)
+		}
+		if databaseRequest.Annotations[PendingReasonAnnotation] != message {
+			r.Recorder.Event(databaseRequest, corev1.EventTypeNormal, ReasonNoMatchingDatabase, message)
+		}
+		return false, r.setRequestPhase(ctx, databaseRequest, kubernetes.DatabasePending, message)
+	}
+
+	if database.Spec.DatabaseRequest == nil {
+		// The update carries the resourceVersion the Database was listed with, so when two requests race
+		// for the same Database only one of them succeeds and the other one is requeued.
+		database.Spec.DatabaseRequest = &corev1.ObjectReference{
+			Name:      databaseRequest.Name,
+			Namespace: databaseRequest.Namespace,
+			UID:       databaseRequest.UID,
+		}
+		if err := r.Update(ctx, database); err != nil {
+			log.Error(err, "Can't claim database", "Database", database.Name)
+			return false, err
+		}
+	}
+
+	databaseRequest.Spec.ExistingDatabaseName = database.Name
+	if err := r.Update(ctx, databaseRequest); err != nil {
+		return false, err
+	}
+	log.Info("Successfully claimed database", "Database", database.Name)
+
+	return true, nil
+}
+
+// selectDatabase returns the Database to bind the DatabaseRequest to. A Database already pointing back at the request
+// is preferred, so a claim interrupted before the request was updated is completed. Otherwise the oldest matching
+// Pending Database of a compatible class is chosen.
+func (r *Reconciler) selectDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, selector labels.Selector) (*databasev1alpha1.Database, error) {
+	var databaseList databasev1alpha1.DatabaseList
+	if err := r.List(ctx, &databaseList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var candidates []*databasev1alpha1.Database
+	for i := range databaseList.Items {
+		database := &databaseList.Items[i]
+		if !database.DeletionTimestamp.IsZero() || kubernetes.GetPhase(database) != kubernetes.DatabasePending {
+			continue
+		}
+		if databaseRequest.Spec.DatabaseClassName != "" && database.Spec.DatabaseClassName != databaseRequest.Spec.DatabaseClassName {
+			continue
+		}
+		if kubernetes.IsBoundTo(database, databaseRequest) {
+			return database, nil
+		}
+		if database.Spec.DatabaseRequest == nil {
+			candidates = append(candidates, database)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].CreationTimestamp.Equal(&candidates[j].CreationTimestamp) {
+			return candidates[i].CreationTimestamp.Before(&candidates[j].CreationTimestamp)
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates[0], nil
+}
+
+// setRequestPhase sets the binding phase of the DatabaseRequest together with the reason it is Pending.
+func (r *Reconciler) setRequestPhase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, phase kubernetes.DatabasePhase, reason string) error {
+	if databaseRequest.Labels[kubernetes.PhaseLabel] == string(phase) && databaseRequest.Annotations[PendingReasonAnnotation] == reason {
+		return nil
+	}
+
+	original := databaseRequest.DeepCopy()
+	if databaseRequest.Labels == nil {
+		databaseRequest.Labels = map[string]string{}
+	}
+	databaseRequest.Labels[kubernetes.PhaseLabel] = string(phase)
+	if databaseRequest.Annotations == nil {
+		databaseRequest.Annotations = map[string]string{}
+	}
+	if reason == "" {
+		delete(databaseRequest.Annotations, PendingReasonAnnotation)
+	} else {
+		databaseRequest.Annotations[PendingReasonAnnotation] = reason
+	}
+
+	return r.Patch(ctx, databaseRequest, client.MergeFrom(original))
+}
+
+// requestsForDatabase maps a Database to the DatabaseRequest it is bound to and, while it is Pending,
+// to the requests still waiting for a Database matching their selector.
+func (r *Reconciler) requestsForDatabase(obj client.Object) []reconcile.Request {
+	database, ok := obj.(*databasev1alpha1.Database)
+	if !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	if ref := database.Spec.DatabaseRequest; ref != nil {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}})
+	}
+	if database.Spec.DatabaseRequest != nil || kubernetes.GetPhase(database) != kubernetes.DatabasePending {
+		return requests
+	}
+
+	var databaseRequestList databasev1alpha1.DatabaseRequestList
+	if err := r.List(context.Background(), &databaseRequestList); err != nil {
+		r.Log.Error(err, "Failed to list DatabaseRequests")
+		return requests
+	}
+	for _, databaseRequest := range databaseRequestList.Items {
+		if _, ok := databaseRequest.Annotations[SelectorAnnotation]; ok && databaseRequest.Spec.ExistingDatabaseName == "" {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&databaseRequest)})
+		}
+	}
+
+	return requests
+}
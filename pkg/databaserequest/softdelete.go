@@ -0,0 +1,67 @@
+package databaserequest
+
+import (
+	"context"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteDatabase deletes the Database of a deleted DatabaseRequest. If the DatabaseClass defines a deletion grace period
+// the Database is moved to the PendingDeletion phase instead, and deleted by the sidecar once the grace period has passed.
+func (r *Reconciler) deleteDatabase(ctx context.Context, databaseRequest *databasev1alpha1.DatabaseRequest, database *databasev1alpha1.Database) error {
+	log := r.Log.WithValues("DatabaseRequest", client.ObjectKeyFromObject(databaseRequest))
+
+	gracePeriod, err := r.deletionGracePeriod(ctx, database)
+	if err != nil {
+		log.Error(err, "Can't get deletion grace period", "Database", database.Name)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeWarning, ReasonDatabaseDeleteFailed, "Failed to delete Database %s: %v", database.Name, err)
+		return err
+	}
+
+	if gracePeriod > 0 {
+		deleteAfter := time.Now().Add(gracePeriod)
+		if err := kubernetes.MarkPendingDeletion(ctx, r.Client, database, deleteAfter); err != nil {
+			log.Error(err, "Error marking database for deletion", "Database", database.Name)
+			return err
+		}
+		deleteAfterValue := database.Annotations[kubernetes.DeleteAfterAnnotation]
+		log.Info("Database pending deletion", "Database", database.Name, "deleteAfter", deleteAfterValue)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabasePendingDeletion, "Database %s is deleted after %s", database.Name, deleteAfterValue)
+		r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonDatabasePendingDeletion, "Deleted after %s unless DatabaseRequest %s/%s is created again", deleteAfterValue, databaseRequest.Namespace, databaseRequest.Name)
+		return nil
+	}
+
+	if err := r.Delete(ctx, database); err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "Error deleting database", "Database", database.Name)
+		r.Recorder.Eventf(databaseRequest, corev1.EventTypeWarning, ReasonDatabaseDeleteFailed, "Failed to delete Database %s: %v", database.Name, err)
+		return err
+	}
+	log.Info("Successfully deleted database", "Database", database.Name)
+	r.Recorder.Eventf(databaseRequest, corev1.EventTypeNormal, ReasonDatabaseDeleted, "Deleted Database %s", database.Name)
+	r.Recorder.Eventf(database, corev1.EventTypeNormal, ReasonDatabaseDeleted, "Deleted on removal of DatabaseRequest %s/%s", databaseRequest.Namespace, databaseRequest.Name)
+
+	return nil
+}
+
+// deletionGracePeriod returns the deletion grace period of the DatabaseClass of the Database. Only databases the
+// driver deletes have a grace period, retained ones are kept anyway.
+func (r *Reconciler) deletionGracePeriod(ctx context.Context, database *databasev1alpha1.Database) (time.Duration, error) {
+	if database.Spec.DeletionPolicy != databasev1alpha1.DeletionPolicyDelete || database.Spec.DatabaseClassName == "" {
+		return 0, nil
+	}
+
+	databaseClass := &databasev1alpha1.DatabaseClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: database.Spec.DatabaseClassName}, databaseClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return kubernetes.GetDeletionGracePeriod(databaseClass)
+}
@@ -0,0 +1,78 @@
+package kubernetes
+
+import (
+	"context"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PhaseLabel holds the binding phase of a Database or DatabaseRequest.
+	PhaseLabel = "database.plural.sh/phase"
+
+	// ProvisionedByAnnotation marks a Database as dynamically provisioned for a DatabaseRequest and holds the driver name.
+	// Databases without it were created statically by an administrator.
+	ProvisionedByAnnotation = "database.plural.sh/provisioned-by"
+)
+
+// DatabasePhase is the binding phase of a Database, modeled after the PersistentVolume phases.
+type DatabasePhase string
+
+const (
+	// DatabasePending is used for Databases and DatabaseRequests that are not bound yet.
+	DatabasePending DatabasePhase = "Pending"
+	// DatabaseBound is used for Databases and DatabaseRequests bound to each other.
+	DatabaseBound DatabasePhase = "Bound"
+	// DatabaseReleased is used for retained Databases whose DatabaseRequest was deleted.
+	// They are not bound again until an administrator clears spec.databaseRequest.
+	DatabaseReleased DatabasePhase = "Released"
+	// DatabaseFailed is used for Databases the driver failed to provision in time or to delete.
+	DatabaseFailed DatabasePhase = "Failed"
+	// DatabasePendingDeletion is used for Databases whose DatabaseRequest was deleted during the deletion grace period
+	// of their DatabaseClass. A new DatabaseRequest of the same name binds them again, otherwise they are deleted afterwards.
+	DatabasePendingDeletion DatabasePhase = "PendingDeletion"
+)
+
+// GetPhase returns the binding phase of the Database, Databases without a phase are Pending.
+func GetPhase(database *databasev1alpha1.Database) DatabasePhase {
+	if phase := database.Labels[PhaseLabel]; phase != "" {
+		return DatabasePhase(phase)
+	}
+	return DatabasePending
+}
+
+// SetPhase patches the binding phase label of a Database or DatabaseRequest.
+func SetPhase(ctx context.Context, client ctrlruntimeclient.Client, obj ctrlruntimeclient.Object, phase DatabasePhase) error {
+	if obj.GetLabels()[PhaseLabel] == string(phase) {
+		return nil
+	}
+
+	original := obj.DeepCopyObject().(ctrlruntimeclient.Object)
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[PhaseLabel] = string(phase)
+	obj.SetLabels(labels)
+
+	return client.Patch(ctx, obj, ctrlruntimeclient.MergeFrom(original))
+}
+
+// IsDynamicallyProvisioned returns true if the Database was generated for a DatabaseRequest.
+// Databases without ProvisionedByAnnotation are treated as statically provisioned and only deleted if their
+// deletion policy says so. Databases generated before it was introduced get it when their request adopts them.
+func IsDynamicallyProvisioned(database *databasev1alpha1.Database) bool {
+	return database.Annotations[ProvisionedByAnnotation] != ""
+}
+
+// IsBoundTo returns true if the Database points back at the DatabaseRequest. Like the claimRef of a
+// PersistentVolume the reference holds the UID of the request, so a new request of the same name
+// does not bind to a Database released by its predecessor. References without a UID match by name.
+func IsBoundTo(database *databasev1alpha1.Database, databaseRequest *databasev1alpha1.DatabaseRequest) bool {
+	ref := database.Spec.DatabaseRequest
+	if ref == nil || ref.Namespace != databaseRequest.Namespace || ref.Name != databaseRequest.Name {
+		return false
+	}
+	return ref.UID == "" || ref.UID == databaseRequest.UID
+}
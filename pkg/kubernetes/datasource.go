@@ -0,0 +1,13 @@
+package kubernetes
+
+const (
+	// DataSourceDatabaseIDKey holds the ID of the database a Database is cloned from. It is set as annotation
+	// on the generated Database and passed to the driver as parameter of the same name.
+	DataSourceDatabaseIDKey = "database.plural.sh/data-source-database-id"
+	// DataSourceSnapshotIDKey holds the ID of the driver snapshot a Database is restored from. It is set as
+	// annotation on the generated Database and passed to the driver as parameter of the same name.
+	DataSourceSnapshotIDKey = "database.plural.sh/data-source-snapshot-id"
+)
+
+// DataSourceKeys lists the annotations describing the data source of a Database.
+var DataSourceKeys = []string{DataSourceDatabaseIDKey, DataSourceSnapshotIDKey}
@@ -0,0 +1,81 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IsDefaultClassAnnotation marks a DatabaseClass as the cluster default, or a DatabaseAccessClass
+// as the default of its driver, when set to "true".
+const IsDefaultClassAnnotation = "database.plural.sh/is-default-class"
+
+// IsDefaultClass returns true if the class is annotated as a default class.
+func IsDefaultClass(obj ctrlruntimeclient.Object) bool {
+	return obj.GetAnnotations()[IsDefaultClassAnnotation] == "true"
+}
+
+// GetDefaultDatabaseClass returns the DatabaseClass marked as the cluster default.
+// It fails when none or more than one class is marked.
+func GetDefaultDatabaseClass(ctx context.Context, client ctrlruntimeclient.Reader) (*databasev1alpha1.DatabaseClass, error) {
+	var databaseClassList databasev1alpha1.DatabaseClassList
+	if err := client.List(ctx, &databaseClassList); err != nil {
+		return nil, err
+	}
+
+	var defaults []*databasev1alpha1.DatabaseClass
+	for i := range databaseClassList.Items {
+		if IsDefaultClass(&databaseClassList.Items[i]) {
+			defaults = append(defaults, &databaseClassList.Items[i])
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		return nil, fmt.Errorf("no DatabaseClass is marked as default with the %s annotation", IsDefaultClassAnnotation)
+	case 1:
+		return defaults[0], nil
+	default:
+		names := make([]string, 0, len(defaults))
+		for _, class := range defaults {
+			names = append(names, class.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%d DatabaseClasses are marked as default: %s", len(defaults), strings.Join(names, ", "))
+	}
+}
+
+// GetDefaultDatabaseAccessClass returns the DatabaseAccessClass marked as the default of the given driver.
+// It fails when none or more than one class of the driver is marked.
+func GetDefaultDatabaseAccessClass(ctx context.Context, client ctrlruntimeclient.Reader, driverName string) (*databasev1alpha1.DatabaseAccessClass, error) {
+	var databaseAccessClassList databasev1alpha1.DatabaseAccessClassList
+	if err := client.List(ctx, &databaseAccessClassList); err != nil {
+		return nil, err
+	}
+
+	var defaults []*databasev1alpha1.DatabaseAccessClass
+	for i := range databaseAccessClassList.Items {
+		class := &databaseAccessClassList.Items[i]
+		if IsDefaultClass(class) && strings.EqualFold(class.DriverName, driverName) {
+			defaults = append(defaults, class)
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		return nil, fmt.Errorf("no DatabaseAccessClass of driver %s is marked as default with the %s annotation", driverName, IsDefaultClassAnnotation)
+	case 1:
+		return defaults[0], nil
+	default:
+		names := make([]string, 0, len(defaults))
+		for _, class := range defaults {
+			names = append(names, class.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%d DatabaseAccessClasses of driver %s are marked as default: %s", len(defaults), driverName, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,13 @@
+package kubernetes
+
+import (
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ExpiredAtAnnotation marks a DatabaseAccess as expired. Its account was revoked and its Secret deleted.
+const ExpiredAtAnnotation = "database.plural.sh/expired-at"
+
+// IsExpired returns true if the DatabaseAccess expired. Expired accesses are never granted again.
+func IsExpired(obj ctrlruntimeclient.Object) bool {
+	return obj.GetAnnotations()[ExpiredAtAnnotation] != ""
+}
@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DeletionGracePeriodAnnotation defines on a DatabaseClass how long a Database with the Delete policy is kept
+	// after its DatabaseRequest was deleted, e.g. "72h". Databases are deleted right away without it.
+	DeletionGracePeriodAnnotation = "database.plural.sh/deletion-grace-period"
+	// DeleteAfterAnnotation records when a Database pending deletion is deleted.
+	DeleteAfterAnnotation = "database.plural.sh/delete-after"
+)
+
+// GetDeletionGracePeriod returns the deletion grace period of the DatabaseClass.
+func GetDeletionGracePeriod(databaseClass *databasev1alpha1.DatabaseClass) (time.Duration, error) {
+	value, ok := databaseClass.Annotations[DeletionGracePeriodAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation: %w", DeletionGracePeriodAnnotation, err)
+	}
+	if gracePeriod < 0 {
+		return 0, fmt.Errorf("invalid %s annotation: must not be negative", DeletionGracePeriodAnnotation)
+	}
+	return gracePeriod, nil
+}
+
+// MarkPendingDeletion moves the Database to the PendingDeletion phase until deleteAfter.
+func MarkPendingDeletion(ctx context.Context, client ctrlruntimeclient.Client, database *databasev1alpha1.Database, deleteAfter time.Time) error {
+	original := database.DeepCopy()
+	if database.Labels == nil {
+		database.Labels = map[string]string{}
+	}
+	if database.Annotations == nil {
+		database.Annotations = map[string]string{}
+	}
+	database.Labels[PhaseLabel] = string(DatabasePendingDeletion)
+	database.Annotations[DeleteAfterAnnotation] = deleteAfter.UTC().Format(time.RFC3339)
+
+	return client.Patch(ctx, database, ctrlruntimeclient.MergeFrom(original))
+}
+
+// IsRestorableBy returns true if the Database is pending deletion and was bound to a DatabaseRequest
+// with the name and namespace of the given one, which then takes the Database over.
+func IsRestorableBy(database *databasev1alpha1.Database, databaseRequest *databasev1alpha1.DatabaseRequest) bool {
+	ref := database.Spec.DatabaseRequest
+	return GetPhase(database) == DatabasePendingDeletion && database.DeletionTimestamp.IsZero() && ref != nil &&
+		ref.Namespace == databaseRequest.Namespace && ref.Name == databaseRequest.Name
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "database_interface"
+
+var (
+	// DriverRequestDuration tracks the latency of gRPC calls made to the driver.
+	DriverRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "driver",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of gRPC requests to the driver, partitioned by method and status code.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"method", "code"})
+
+	// DatabasesProvisioned counts databases successfully created by the driver.
+	DatabasesProvisioned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "databases_provisioned_total",
+		Help:      "Number of databases provisioned, partitioned by driver and class.",
+	}, []string{"driver", "class"})
+
+	// DatabasesFailed counts failed database creations.
+	DatabasesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "databases_failed_total",
+		Help:      "Number of failed database provisioning attempts, partitioned by driver and class.",
+	}, []string{"driver", "class"})
+
+	// AccessesGranted counts database accesses successfully granted by the driver.
+	AccessesGranted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accesses_granted_total",
+		Help:      "Number of database accesses granted, partitioned by driver and class.",
+	}, []string{"driver", "class"})
+
+	// AccessesFailed counts failed access grants.
+	AccessesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accesses_failed_total",
+		Help:      "Number of failed database access grants, partitioned by driver and class.",
+	}, []string{"driver", "class"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		DriverRequestDuration,
+		DatabasesProvisioned,
+		DatabasesFailed,
+		AccessesGranted,
+		AccessesFailed,
+	)
+}
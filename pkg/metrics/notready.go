@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	KindDatabase        = "Database"
+	KindDatabaseRequest = "DatabaseRequest"
+	KindDatabaseAccess  = "DatabaseAccess"
+
+	collectTimeout = 10 * time.Second
+)
+
+var notReadyDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "objects_not_ready"),
+	"Number of objects that are not ready longer than the stuck threshold, partitioned by kind.",
+	[]string{"kind"}, nil,
+)
+
+// NotReadyCollector reports objects stuck in a not ready state. The objects are read on every scrape,
+// so the reader should be backed by the manager cache.
+type NotReadyCollector struct {
+	Reader client.Reader
+	// Kinds lists the kinds reported by the collector.
+	Kinds []string
+	// DriverName restricts Databases and DatabaseAccesses to the given driver when set.
+	DriverName string
+	// Threshold is the time an object may spend not ready before it is reported.
+	Threshold time.Duration
+}
+
+var _ prometheus.Collector = &NotReadyCollector{}
+
+func (c *NotReadyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- notReadyDesc
+}
+
+func (c *NotReadyCollector) Collect(ch chan<- prometheus.Metric) {
+	log := logf.Log.WithName("metrics")
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	for _, kind := range c.Kinds {
+		count, err := c.countNotReady(ctx, kind)
+		if err != nil {
+			log.Error(err, "Failed to count not ready objects", "kind", kind)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(notReadyDesc, prometheus.GaugeValue, float64(count), kind)
+	}
+}
+
+func (c *NotReadyCollector) countNotReady(ctx context.Context, kind string) (int, error) {
+	deadline := time.Now().Add(-c.Threshold)
+	stuck := func(created time.Time, ready bool) bool {
+		return !ready && created.Before(deadline)
+	}
+	count := 0
+
+	switch kind {
+	case KindDatabase:
+		var databaseList databasev1alpha1.DatabaseList
+		if err := c.Reader.List(ctx, &databaseList); err != nil {
+			return 0, err
+		}
+		for _, database := range databaseList.Items {
+			if c.DriverName != "" && !strings.EqualFold(database.Spec.DriverName, c.DriverName) {
+				continue
+			}
+			if stuck(database.CreationTimestamp.Time, database.Status.Ready) {
+				count++
+			}
+		}
+	case KindDatabaseRequest:
+		var databaseRequestList databasev1alpha1.DatabaseRequestList
+		if err := c.Reader.List(ctx, &databaseRequestList); err != nil {
+			return 0, err
+		}
+		for _, databaseRequest := range databaseRequestList.Items {
+			if stuck(databaseRequest.CreationTimestamp.Time, databaseRequest.Status.Ready) {
+				count++
+			}
+		}
+	case KindDatabaseAccess:
+		var databaseAccessClassList databasev1alpha1.DatabaseAccessClassList
+		if err := c.Reader.List(ctx, &databaseAccessClassList); err != nil {
+			return 0, err
+		}
+		drivers := map[string]string{}
+		for _, databaseAccessClass := range databaseAccessClassList.Items {
+			drivers[databaseAccessClass.Name] = databaseAccessClass.DriverName
+		}
+
+		var databaseAccessList databasev1alpha1.DatabaseAccessList
+		if err := c.Reader.List(ctx, &databaseAccessList); err != nil {
+			return 0, err
+		}
+		for _, databaseAccess := range databaseAccessList.Items {
+			if c.DriverName != "" && !strings.EqualFold(drivers[databaseAccess.Spec.DatabaseAccessClassName], c.DriverName) {
+				continue
+			}
+			// Expired accesses are revoked on purpose and never granted again.
+			if kubernetes.IsExpired(&databaseAccess) {
+				continue
+			}
+			if stuck(databaseAccess.CreationTimestamp.Time, databaseAccess.Status.AccessGranted) {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
@@ -18,9 +18,12 @@ package provisioner
 import (
 	"context"
 	"encoding/json"
+	"path"
 	"time"
 
+	"github.com/pluralsh/database-interface-controller/pkg/metrics"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 )
 
@@ -44,3 +47,18 @@ func apiLogger(ctx context.Context, api string,
 
 	return err
 }
+
+func metricsInterceptor(ctx context.Context, api string,
+	req, resp interface{},
+	grpcConn *grpc.ClientConn,
+	apiCall grpc.UnaryInvoker,
+	opts ...grpc.CallOption) error {
+
+	start := time.Now()
+	err := apiCall(ctx, api, req, resp, grpcConn, opts...)
+	metrics.DriverRequestDuration.
+		WithLabelValues(path.Base(api), status.Code(err).String()).
+		Observe(time.Since(start).Seconds())
+
+	return err
+}
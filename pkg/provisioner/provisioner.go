@@ -24,6 +24,7 @@ import (
 	databasespec "github.com/pluralsh/database-interface-api/spec"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
 	"k8s.io/klog/v2"
 )
 
@@ -33,11 +34,21 @@ const (
 )
 
 func NewDefaultProvisionerClient(ctx context.Context, address string, debug bool) (*ProvisionerClient, error) {
+	return NewProvisionerClientWithTLS(ctx, address, nil, debug)
+}
+
+// NewProvisionerClientWithTLS creates a new GRPCClient for either a unix domain socket or,
+// when tlsConfig is set, a tcp address secured with mutual TLS
+func NewProvisionerClientWithTLS(ctx context.Context, address string, tlsConfig *TLSConfig, debug bool) (*ProvisionerClient, error) {
+	addr, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
 	backoffConfiguration := backoff.DefaultConfig
 	backoffConfiguration.MaxDelay = maxGrpcBackoff
 
 	dialOpts := []grpc.DialOption{
-		grpc.WithInsecure(), // strictly restricting to local Unix domain socket
 		grpc.WithConnectParams(grpc.ConnectParams{
 			Backoff:           backoffConfiguration,
 			MinConnectTimeout: grpcDialTimeout,
@@ -45,26 +56,36 @@ func NewDefaultProvisionerClient(ctx context.Context, address string, debug bool
 		grpc.WithBlock(), // block until connection succeeds
 	}
 
-	interceptors := []grpc.UnaryClientInterceptor{}
+	switch {
+	case tlsConfig != nil:
+		clientTLSConfig, err := tlsConfig.clientTLSConfig(addr.Hostname())
+		if err != nil {
+			klog.ErrorS(err, "Invalid TLS configuration")
+			return nil, errors.Wrap(err, "Invalid argument")
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(clientTLSConfig)))
+	case addr.Scheme == "unix":
+		dialOpts = append(dialOpts, grpc.WithInsecure()) // strictly restricting to local Unix domain socket
+	default:
+		err := errors.New("TCP address requires TLS")
+		klog.ErrorS(err, "Insecure transport", "address", address)
+		return nil, errors.Wrap(err, "Invalid argument")
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{metricsInterceptor}
 	if debug {
 		interceptors = append(interceptors, apiLogger)
 	}
 	return NewProvisionerClient(ctx, address, dialOpts, interceptors)
 }
 
-// NewCOSIProvisionerClient creates a new GRPCClient that only supports unix domain sockets
+// NewProvisionerClient creates a new GRPCClient that supports unix domain sockets and tcp addresses
 func NewProvisionerClient(ctx context.Context, address string, dialOpts []grpc.DialOption, interceptors []grpc.UnaryClientInterceptor) (*ProvisionerClient, error) {
-	addr, err := url.Parse(address)
+	target, err := dialTarget(address)
 	if err != nil {
 		return nil, err
 	}
 
-	if addr.Scheme != "unix" {
-		err := errors.New("Address must be a unix domain socket")
-		klog.ErrorS(err, "Unsupported scheme", "expected", "unix", "found", addr.Scheme)
-		return nil, errors.Wrap(err, "Invalid argument")
-	}
-
 	for _, interceptor := range interceptors {
 		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(interceptor))
 	}
@@ -72,7 +93,7 @@ func NewProvisionerClient(ctx context.Context, address string, dialOpts []grpc.D
 	ctx, cancel := context.WithTimeout(ctx, maxGrpcBackoff)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, address, dialOpts...)
+	conn, err := grpc.DialContext(ctx, target, dialOpts...)
 	if err != nil {
 		klog.ErrorS(err, "Connection failed", "address", address)
 		return nil, err
@@ -85,11 +106,64 @@ func NewProvisionerClient(ctx context.Context, address string, dialOpts []grpc.D
 	}, nil
 }
 
+// dialTarget converts a unix:// or tcp:// driver address into a gRPC dial target
+func dialTarget(address string) (string, error) {
+	addr, err := url.Parse(address)
+	if err != nil {
+		return "", err
+	}
+
+	switch addr.Scheme {
+	case "unix":
+		return address, nil
+	case "tcp":
+		if addr.Host == "" {
+			err := errors.New("Address must contain a host and port")
+			klog.ErrorS(err, "Invalid address", "address", address)
+			return "", errors.Wrap(err, "Invalid argument")
+		}
+		return addr.Host, nil
+	default:
+		err := errors.New("Address must be a unix domain socket or a tcp address")
+		klog.ErrorS(err, "Unsupported scheme", "expected", "unix or tcp", "found", addr.Scheme)
+		return "", errors.Wrap(err, "Invalid argument")
+	}
+}
+
 func NewDefaultProvisionerServer(address string,
 	identityServer databasespec.IdentityServer,
 	provisionerServer databasespec.ProvisionerServer) (*ProvisionerServer, error) {
 
-	return NewProvisionerServer(address, identityServer, provisionerServer, []grpc.ServerOption{})
+	return NewProvisionerServerWithTLS(address, nil, identityServer, provisionerServer)
+}
+
+// NewProvisionerServerWithTLS creates a server listening on either a unix domain socket or,
+// when tlsConfig is set, a tcp address secured with mutual TLS
+func NewProvisionerServerWithTLS(address string,
+	tlsConfig *TLSConfig,
+	identityServer databasespec.IdentityServer,
+	provisionerServer databasespec.ProvisionerServer) (*ProvisionerServer, error) {
+
+	addr, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	listenOpts := []grpc.ServerOption{}
+	if tlsConfig != nil {
+		serverTLSConfig, err := tlsConfig.serverTLSConfig()
+		if err != nil {
+			klog.ErrorS(err, "Invalid TLS configuration")
+			return nil, errors.Wrap(err, "Invalid argument")
+		}
+		listenOpts = append(listenOpts, grpc.Creds(credentials.NewTLS(serverTLSConfig)))
+	} else if addr.Scheme != "unix" {
+		err := errors.New("TCP address requires TLS")
+		klog.ErrorS(err, "Insecure transport", "address", address)
+		return nil, errors.Wrap(err, "Invalid argument")
+	}
+
+	return NewProvisionerServer(address, identityServer, provisionerServer, listenOpts)
 }
 
 func NewProvisionerServer(address string,
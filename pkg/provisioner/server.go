@@ -34,19 +34,13 @@ type ProvisionerServer struct {
 }
 
 func (s *ProvisionerServer) Run(ctx context.Context) error {
-	addr, err := url.Parse(s.address)
+	network, listenAddress, err := listenTarget(s.address)
 	if err != nil {
 		return err
 	}
 
-	if addr.Scheme != "unix" {
-		err := errors.New("Address must be a unix domain socket")
-		klog.ErrorS(err, "Unsupported scheme", "expected", "unix", "found", addr.Scheme)
-		return errors.Wrap(err, "Invalid argument")
-	}
-
 	listenConfig := net.ListenConfig{}
-	listener, err := listenConfig.Listen(ctx, "unix", addr.Path)
+	listener, err := listenConfig.Listen(ctx, network, listenAddress)
 	if err != nil {
 		klog.ErrorS(err, "Failed to start server")
 		return errors.Wrap(err, "Failed to start server")
@@ -76,3 +70,22 @@ func (s *ProvisionerServer) Run(ctx context.Context) error {
 		return err
 	}
 }
+
+// listenTarget converts a unix:// or tcp:// address into the network and address to listen on
+func listenTarget(address string) (string, string, error) {
+	addr, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch addr.Scheme {
+	case "unix":
+		return "unix", addr.Path, nil
+	case "tcp":
+		return "tcp", addr.Host, nil
+	default:
+		err := errors.New("Address must be a unix domain socket or a tcp address")
+		klog.ErrorS(err, "Unsupported scheme", "expected", "unix or tcp", "found", addr.Scheme)
+		return "", "", errors.Wrap(err, "Invalid argument")
+	}
+}
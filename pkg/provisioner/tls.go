@@ -0,0 +1,238 @@
+// Copyright 2021 The Kubernetes Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+// TLSConfig describes the mutual TLS setup used for tcp:// connections between the sidecar and the driver.
+// The files are re-read whenever they change on disk, so rotated certificates are picked up without a restart.
+type TLSConfig struct {
+	// CertFile and KeyFile hold the certificate presented to the peer.
+	CertFile string
+	KeyFile  string
+
+	// CAFile holds the CA bundle used to verify the peer certificate.
+	CAFile string
+
+	// ServerName is the name the driver certificate must be valid for.
+	// Only used by the client, defaults to the host of the driver address.
+	ServerName string
+
+	// AllowedIdentities restricts the accepted peers to certificates carrying one
+	// of these names as common name or DNS/URI SAN. Empty accepts any peer signed by the CA.
+	AllowedIdentities []string
+}
+
+func (c *TLSConfig) validate() error {
+	if c.CertFile == "" || c.KeyFile == "" || c.CAFile == "" {
+		return errors.New("TLS certificate, key and CA files are required")
+	}
+	return nil
+}
+
+// clientTLSConfig returns the tls.Config used by the sidecar to dial the driver.
+func (c *TLSConfig) clientTLSConfig(serverName string) (*tls.Config, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if c.ServerName != "" {
+		serverName = c.ServerName
+	}
+
+	reloader := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile, caFile: c.CAFile}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The peer is verified in VerifyConnection against the current CA bundle, which lets the bundle be rotated.
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		},
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return reloader.verifyPeer(state, serverName, x509.ExtKeyUsageServerAuth, c.AllowedIdentities)
+		},
+	}, nil
+}
+
+// serverTLSConfig returns the tls.Config used by the driver to accept sidecar connections.
+func (c *TLSConfig) serverTLSConfig() (*tls.Config, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	reloader := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile, caFile: c.CAFile}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The client certificate is verified in VerifyConnection against the current CA bundle.
+		ClientAuth: tls.RequireAnyClientCert,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		},
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return reloader.verifyPeer(state, "", x509.ExtKeyUsageClientAuth, c.AllowedIdentities)
+		},
+	}, nil
+}
+
+// certReloader keeps the key pair and CA bundle in memory and reloads them when one of the files changes.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	roots    *x509.CertPool
+	modTimes []time.Time
+}
+
+func (r *certReloader) reload() error {
+	modTimes, err := r.currentModTimes()
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && equalTimes(r.modTimes, modTimes)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load TLS key pair")
+	}
+	caBundle, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read CA file")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caBundle) {
+		return errors.New("CA file does not contain any PEM encoded certificate")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.roots = roots
+	r.modTimes = modTimes
+	klog.V(3).InfoS("Loaded TLS certificates", "cert", r.certFile, "ca", r.caFile)
+
+	return nil
+}
+
+func (r *certReloader) currentModTimes() ([]time.Time, error) {
+	modTimes := make([]time.Time, 0, 3)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to stat TLS file")
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	if err := r.reload(); err != nil {
+		// Keep serving the last good certificate while the files are being rotated.
+		klog.ErrorS(err, "Failed to reload TLS certificates")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) verifyPeer(state tls.ConnectionState, serverName string, usage x509.ExtKeyUsage, allowedIdentities []string) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("Peer did not present a certificate")
+	}
+	if err := r.reload(); err != nil {
+		klog.ErrorS(err, "Failed to reload TLS certificates")
+	}
+
+	r.mu.RLock()
+	roots := r.roots
+	r.mu.RUnlock()
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}); err != nil {
+		return errors.Wrap(err, "Failed to verify peer certificate")
+	}
+
+	if len(allowedIdentities) == 0 {
+		return nil
+	}
+	for _, identity := range peerIdentities(leaf) {
+		for _, allowed := range allowedIdentities {
+			if identity == allowed {
+				return nil
+			}
+		}
+	}
+	err := errors.New("Peer identity is not allowed")
+	klog.ErrorS(err, "Rejected TLS peer", "subject", leaf.Subject.String())
+	return err
+}
+
+func peerIdentities(cert *x509.Certificate) []string {
+	identities := []string{}
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
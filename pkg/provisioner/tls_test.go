@@ -0,0 +1,356 @@
+// Copyright 2021 The Kubernetes Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func nextSerial() *big.Int {
+	serial++
+	return big.NewInt(serial)
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          nextSerial(),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM encoded certificate and key for the given names signed by the CA.
+func (ca *testCA) issue(t *testing.T, commonName string, dnsNames []string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: nextSerial(),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes the file and moves its modification time forward, so a rewrite within the
+// timestamp resolution of the file system is still noticed by the reloader.
+func writeFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+	var modTime time.Time
+	if info, err := os.Stat(file); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !modTime.IsZero() {
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeTLSConfig writes the key pair and CA bundle to a new directory and returns a TLSConfig referencing them.
+func writeTLSConfig(t *testing.T, cert, key, ca []byte) *TLSConfig {
+	t.Helper()
+	dir := t.TempDir()
+	config := &TLSConfig{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	writeFile(t, config.CertFile, cert)
+	writeFile(t, config.KeyFile, key)
+	writeFile(t, config.CAFile, ca)
+	return config
+}
+
+// handshake connects a client and a server over loopback TCP and returns the handshake errors of both sides.
+// With TLS 1.3 the client completes its handshake before the server verifies the client certificate, so a
+// rejected client shows up in the server error.
+func handshake(t *testing.T, clientConfig, serverConfig *tls.Config) (tls.ConnectionState, error, error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		server := tls.Server(conn, serverConfig)
+		err = server.Handshake()
+		if err == nil {
+			// Wait for the client to close, so its handshake is never cut short.
+			_, _ = server.Read(make([]byte, 1))
+		}
+		serverErr <- err
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tls.Client(conn, clientConfig)
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	clientErr := client.Handshake()
+	state := client.ConnectionState()
+	conn.Close()
+
+	return state, clientErr, <-serverErr
+}
+
+type testPKI struct {
+	ca     *testCA
+	server *TLSConfig
+	client *TLSConfig
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	ca := newTestCA(t, "database-interface-ca")
+	serverCert, serverKey := ca.issue(t, "driver", []string{"driver.example"}, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, "sidecar", nil, x509.ExtKeyUsageClientAuth)
+	return &testPKI{
+		ca:     ca,
+		server: writeTLSConfig(t, serverCert, serverKey, ca.pem),
+		client: writeTLSConfig(t, clientCert, clientKey, ca.pem),
+	}
+}
+
+func (p *testPKI) configs(t *testing.T, serverName string) (*tls.Config, *tls.Config) {
+	t.Helper()
+	clientConfig, err := p.client.clientTLSConfig(serverName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig, err := p.server.serverTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientConfig, serverConfig
+}
+
+func TestMutualTLSHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	state, clientErr, serverErr := handshake(t, clientConfig, serverConfig)
+	if clientErr != nil || serverErr != nil {
+		t.Fatalf("handshake failed: client %v, server %v", clientErr, serverErr)
+	}
+	if got := state.PeerCertificates[0].Subject.CommonName; got != "driver" {
+		t.Errorf("peer %q, want driver", got)
+	}
+}
+
+func TestMutualTLSRejectsUntrustedServer(t *testing.T) {
+	pki := newTestPKI(t)
+	other := newTestCA(t, "other-ca")
+	serverCert, serverKey := other.issue(t, "driver", []string{"driver.example"}, x509.ExtKeyUsageServerAuth)
+	pki.server = writeTLSConfig(t, serverCert, serverKey, pki.ca.pem)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	if _, clientErr, _ := handshake(t, clientConfig, serverConfig); clientErr == nil {
+		t.Fatal("client accepted a server certificate signed by another CA")
+	}
+}
+
+func TestMutualTLSRejectsUntrustedClient(t *testing.T) {
+	pki := newTestPKI(t)
+	other := newTestCA(t, "other-ca")
+	clientCert, clientKey := other.issue(t, "sidecar", nil, x509.ExtKeyUsageClientAuth)
+	pki.client = writeTLSConfig(t, clientCert, clientKey, pki.ca.pem)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	if _, _, serverErr := handshake(t, clientConfig, serverConfig); serverErr == nil {
+		t.Fatal("server accepted a client certificate signed by another CA")
+	}
+}
+
+func TestMutualTLSRejectsHostnameMismatch(t *testing.T) {
+	pki := newTestPKI(t)
+	clientConfig, serverConfig := pki.configs(t, "other.example")
+
+	if _, clientErr, _ := handshake(t, clientConfig, serverConfig); clientErr == nil {
+		t.Fatal("client accepted a server certificate for another name")
+	}
+}
+
+func TestMutualTLSRejectsMissingClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	_, serverConfig := pki.configs(t, "driver.example")
+	clientConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: true}
+
+	if _, _, serverErr := handshake(t, clientConfig, serverConfig); serverErr == nil {
+		t.Fatal("server accepted a client without certificate")
+	}
+}
+
+func TestMutualTLSRejectsWrongKeyUsage(t *testing.T) {
+	pki := newTestPKI(t)
+	// A server certificate must not be usable as client certificate.
+	clientCert, clientKey := pki.ca.issue(t, "sidecar", nil, x509.ExtKeyUsageServerAuth)
+	pki.client = writeTLSConfig(t, clientCert, clientKey, pki.ca.pem)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	if _, _, serverErr := handshake(t, clientConfig, serverConfig); serverErr == nil {
+		t.Fatal("server accepted a client certificate without client auth usage")
+	}
+}
+
+func TestMutualTLSAllowedIdentities(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		wantErr bool
+	}{
+		{name: "any peer", allowed: nil},
+		{name: "common name", allowed: []string{"sidecar"}},
+		{name: "not allowed", allowed: []string{"other-sidecar"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pki := newTestPKI(t)
+			pki.server.AllowedIdentities = tt.allowed
+			clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+			_, _, serverErr := handshake(t, clientConfig, serverConfig)
+			if (serverErr != nil) != tt.wantErr {
+				t.Errorf("got server error %v, want error %v", serverErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMutualTLSReloadsRotatedFiles(t *testing.T) {
+	pki := newTestPKI(t)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	state, clientErr, serverErr := handshake(t, clientConfig, serverConfig)
+	if clientErr != nil || serverErr != nil {
+		t.Fatalf("handshake failed: client %v, server %v", clientErr, serverErr)
+	}
+	previousSerial := state.PeerCertificates[0].SerialNumber
+
+	// Rotate the CA: both sides trust the new CA and present certificates issued by it.
+	rotated := newTestCA(t, "rotated-ca")
+	serverCert, serverKey := rotated.issue(t, "driver", []string{"driver.example"}, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := rotated.issue(t, "sidecar", nil, x509.ExtKeyUsageClientAuth)
+	writeFile(t, pki.server.CertFile, serverCert)
+	writeFile(t, pki.server.KeyFile, serverKey)
+	writeFile(t, pki.server.CAFile, rotated.pem)
+	writeFile(t, pki.client.CertFile, clientCert)
+	writeFile(t, pki.client.KeyFile, clientKey)
+	writeFile(t, pki.client.CAFile, rotated.pem)
+
+	state, clientErr, serverErr = handshake(t, clientConfig, serverConfig)
+	if clientErr != nil || serverErr != nil {
+		t.Fatalf("handshake after rotation failed: client %v, server %v", clientErr, serverErr)
+	}
+	if state.PeerCertificates[0].SerialNumber.Cmp(previousSerial) == 0 {
+		t.Error("server still presents the certificate from before the rotation")
+	}
+	if _, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: certPool(rotated)}); err != nil {
+		t.Errorf("server certificate is not issued by the rotated CA: %v", err)
+	}
+}
+
+func TestMutualTLSKeepsCertificateWhileRotating(t *testing.T) {
+	pki := newTestPKI(t)
+	clientConfig, serverConfig := pki.configs(t, "driver.example")
+
+	// A half written key pair is ignored until it is complete.
+	writeFile(t, pki.server.KeyFile, []byte("not a key"))
+	if _, clientErr, serverErr := handshake(t, clientConfig, serverConfig); clientErr != nil || serverErr != nil {
+		t.Fatalf("handshake with a broken key file failed: client %v, server %v", clientErr, serverErr)
+	}
+}
+
+func TestTLSConfigRequiresFiles(t *testing.T) {
+	if _, err := (&TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"}).clientTLSConfig("driver.example"); err == nil {
+		t.Error("client config without CA file accepted")
+	}
+	if _, err := (&TLSConfig{CAFile: "ca.crt"}).serverTLSConfig(); err == nil {
+		t.Error("server config without key pair accepted")
+	}
+	pki := newTestPKI(t)
+	writeFile(t, pki.server.CAFile, []byte("no certificates"))
+	if _, err := pki.server.serverTLSConfig(); err == nil {
+		t.Error("server config with an empty CA bundle accepted")
+	}
+}
+
+func certPool(ca *testCA) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
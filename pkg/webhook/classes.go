@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	databaseaccess "github.com/pluralsh/database-interface-controller/pkg/database-access"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DatabaseClassValidator validates DatabaseClasses at admission time
+type DatabaseClassValidator struct{}
+
+var _ admission.CustomValidator = &DatabaseClassValidator{}
+
+func (v *DatabaseClassValidator) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	databaseClass, ok := obj.(*databasev1alpha1.DatabaseClass)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseClass but got a %T", obj)
+	}
+
+	var allErrs field.ErrorList
+	if databaseClass.DriverName == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("driverName"), ""))
+	}
+	switch databaseClass.DeletionPolicy {
+	case "", databasev1alpha1.DeletionPolicyRetain, databasev1alpha1.DeletionPolicyDelete:
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("deletionPolicy"), databaseClass.DeletionPolicy,
+			[]string{string(databasev1alpha1.DeletionPolicyRetain), string(databasev1alpha1.DeletionPolicyDelete)}))
+	}
+	if _, err := kubernetes.GetDeletionGracePeriod(databaseClass); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(kubernetes.DeletionGracePeriodAnnotation),
+			databaseClass.Annotations[kubernetes.DeletionGracePeriodAnnotation], err.Error()))
+	}
+
+	return invalid(databaseClass, allErrs)
+}
+
+func (v *DatabaseClassValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldDatabaseClass, ok := oldObj.(*databasev1alpha1.DatabaseClass)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseClass but got a %T", oldObj)
+	}
+	if err := v.ValidateCreate(ctx, newObj); err != nil {
+		return err
+	}
+	databaseClass := newObj.(*databasev1alpha1.DatabaseClass)
+
+	return invalid(databaseClass, immutableOnceSet(databaseClass.DriverName, oldDatabaseClass.DriverName, field.NewPath("driverName")))
+}
+
+func (v *DatabaseClassValidator) ValidateDelete(context.Context, runtime.Object) error {
+	return nil
+}
+
+// DatabaseAccessClassValidator validates DatabaseAccessClasses at admission time
+type DatabaseAccessClassValidator struct{}
+
+var _ admission.CustomValidator = &DatabaseAccessClassValidator{}
+
+func (v *DatabaseAccessClassValidator) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	databaseAccessClass, ok := obj.(*databasev1alpha1.DatabaseAccessClass)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccessClass but got a %T", obj)
+	}
+
+	var allErrs field.ErrorList
+	if databaseAccessClass.DriverName == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("driverName"), ""))
+	}
+	if _, err := databaseaccess.ParseAuthenticationType(databaseAccessClass); err != nil {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("authenticationType"), databaseAccessClass.AuthenticationType, databaseaccess.SupportedAuthenticationTypes))
+	}
+	if err := databaseaccess.ValidateDatabaseAccessClass(databaseAccessClass); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations"), field.OmitValueType{}, err.Error()))
+	}
+
+	return invalid(databaseAccessClass, allErrs)
+}
+
+func (v *DatabaseAccessClassValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldDatabaseAccessClass, ok := oldObj.(*databasev1alpha1.DatabaseAccessClass)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccessClass but got a %T", oldObj)
+	}
+	if err := v.ValidateCreate(ctx, newObj); err != nil {
+		return err
+	}
+	databaseAccessClass := newObj.(*databasev1alpha1.DatabaseAccessClass)
+
+	allErrs := immutableOnceSet(databaseAccessClass.DriverName, oldDatabaseAccessClass.DriverName, field.NewPath("driverName"))
+	// Credentials already written would be left behind in the previous sink.
+	for _, key := range []string{databaseaccess.CredentialSinkAnnotation, databaseaccess.VaultMountAnnotation, databaseaccess.VaultPathPrefixAnnotation} {
+		if databaseAccessClass.Annotations[key] != oldDatabaseAccessClass.Annotations[key] {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(key), databaseAccessClass.Annotations[key], "annotation is immutable"))
+		}
+	}
+
+	return invalid(databaseAccessClass, allErrs)
+}
+
+func (v *DatabaseAccessClassValidator) ValidateDelete(context.Context, runtime.Object) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	databaseaccess "github.com/pluralsh/database-interface-controller/pkg/database-access"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DatabaseAccessValidator validates DatabaseAccesses at admission time
+type DatabaseAccessValidator struct {
+	client.Client
+}
+
+var _ admission.CustomValidator = &DatabaseAccessValidator{}
+
+func (v *DatabaseAccessValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	databaseAccess, ok := obj.(*databasev1alpha1.DatabaseAccess)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccess but got a %T", obj)
+	}
+
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if databaseAccess.Spec.DatabaseRequestName == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("databaseRequestName"), ""))
+	}
+	if databaseAccess.Spec.CredentialsSecretName == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("credentialsSecretName"), ""))
+	}
+	if serviceAccount, ok := databaseAccess.Annotations[databaseaccess.ServiceAccountAnnotation]; ok {
+		for _, msg := range validation.IsDNS1123Subdomain(serviceAccount) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(databaseaccess.ServiceAccountAnnotation), serviceAccount, msg))
+		}
+	}
+	// An empty class is resolved to the default DatabaseAccessClass of the driver by the sidecar.
+	if databaseAccess.Spec.DatabaseAccessClassName != "" {
+		if err := v.validatePrivileges(ctx, databaseAccess, specPath); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return invalid(databaseAccess, allErrs)
+}
+
+func (v *DatabaseAccessValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldDatabaseAccess, ok := oldObj.(*databasev1alpha1.DatabaseAccess)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccess but got a %T", oldObj)
+	}
+	databaseAccess, ok := newObj.(*databasev1alpha1.DatabaseAccess)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccess but got a %T", newObj)
+	}
+
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, immutableOnceSet(databaseAccess.Spec.DatabaseRequestName, oldDatabaseAccess.Spec.DatabaseRequestName, specPath.Child("databaseRequestName"))...)
+	allErrs = append(allErrs, immutableOnceSet(databaseAccess.Spec.DatabaseAccessClassName, oldDatabaseAccess.Spec.DatabaseAccessClassName, specPath.Child("databaseAccessClassName"))...)
+	allErrs = append(allErrs, immutableOnceSet(databaseAccess.Spec.CredentialsSecretName, oldDatabaseAccess.Spec.CredentialsSecretName, specPath.Child("credentialsSecretName"))...)
+	if databaseAccess.Annotations[databaseaccess.ServiceAccountAnnotation] != oldDatabaseAccess.Annotations[databaseaccess.ServiceAccountAnnotation] {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(databaseaccess.ServiceAccountAnnotation),
+			databaseAccess.Annotations[databaseaccess.ServiceAccountAnnotation], "annotation is immutable"))
+	}
+	if databaseAccess.Spec.DatabaseAccessClassName != "" && annotationsChanged(oldDatabaseAccess, databaseAccess,
+		databaseaccess.RoleAnnotation, databaseaccess.GrantsAnnotation, databaseaccess.TTLAnnotation, databaseaccess.ExpiresAtAnnotation) {
+		if err := v.validatePrivileges(ctx, databaseAccess, specPath); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return invalid(databaseAccess, allErrs)
+}
+
+func (v *DatabaseAccessValidator) ValidateDelete(context.Context, runtime.Object) error {
+	return nil
+}
+
+// annotationsChanged returns true if one of the annotations differs between the objects
+func annotationsChanged(oldObj, newObj client.Object, keys ...string) bool {
+	for _, key := range keys {
+		if oldObj.GetAnnotations()[key] != newObj.GetAnnotations()[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePrivileges checks that the DatabaseAccessClass exists and allows the privileges and lifetime requested by the DatabaseAccess
+func (v *DatabaseAccessValidator) validatePrivileges(ctx context.Context, databaseAccess *databasev1alpha1.DatabaseAccess, specPath *field.Path) *field.Error {
+	databaseAccessClass := &databasev1alpha1.DatabaseAccessClass{}
+	if err := classExists(ctx, v.Client, databaseAccessClass, databaseAccess.Spec.DatabaseAccessClassName, specPath.Child("databaseAccessClassName")); err != nil {
+		return err
+	}
+	if err := databaseaccess.ValidatePrivileges(databaseAccessClass, databaseAccess); err != nil {
+		return field.Forbidden(field.NewPath("metadata", "annotations"), err.Error())
+	}
+	if err := databaseaccess.ValidateExpiry(databaseAccessClass, databaseAccess); err != nil {
+		return field.Invalid(field.NewPath("metadata", "annotations"), field.OmitValueType{}, err.Error())
+	}
+	return nil
+}
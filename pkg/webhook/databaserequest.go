@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/databaserequest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DatabaseRequestValidator validates DatabaseRequests at admission time
+type DatabaseRequestValidator struct {
+	client.Client
+}
+
+var _ admission.CustomValidator = &DatabaseRequestValidator{}
+
+func (v *DatabaseRequestValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	databaseRequest, ok := obj.(*databasev1alpha1.DatabaseRequest)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseRequest but got a %T", obj)
+	}
+
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	selector, hasSelector := databaseRequest.Annotations[databaserequest.SelectorAnnotation]
+	if databaseRequest.Spec.DatabaseClassName == "" && databaseRequest.Spec.ExistingDatabaseName == "" && !hasSelector {
+		allErrs = append(allErrs, field.Required(specPath.Child("databaseClassName"),
+			fmt.Sprintf("either databaseClassName, existingBucketName or the %s annotation must be set", databaserequest.SelectorAnnotation)))
+	}
+	if hasSelector {
+		if _, err := labels.Parse(selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(databaserequest.SelectorAnnotation), selector, err.Error()))
+		}
+	}
+	allErrs = append(allErrs, validateDataSource(databaseRequest, hasSelector)...)
+	if databaseRequest.Spec.DatabaseClassName != "" {
+		if err := classExists(ctx, v.Client, &databasev1alpha1.DatabaseClass{}, databaseRequest.Spec.DatabaseClassName, specPath.Child("databaseClassName")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return invalid(databaseRequest, allErrs)
+}
+
+func (v *DatabaseRequestValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldDatabaseRequest, ok := oldObj.(*databasev1alpha1.DatabaseRequest)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseRequest but got a %T", oldObj)
+	}
+	databaseRequest, ok := newObj.(*databasev1alpha1.DatabaseRequest)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseRequest but got a %T", newObj)
+	}
+
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, immutableOnceSet(databaseRequest.Spec.DatabaseClassName, oldDatabaseRequest.Spec.DatabaseClassName, specPath.Child("databaseClassName"))...)
+	allErrs = append(allErrs, immutableOnceSet(databaseRequest.Spec.ExistingDatabaseName, oldDatabaseRequest.Spec.ExistingDatabaseName, specPath.Child("existingBucketName"))...)
+	for _, key := range []string{databaserequest.DataSourceAnnotation, databaserequest.DataSourceSnapshotAnnotation} {
+		if databaseRequest.Annotations[key] != oldDatabaseRequest.Annotations[key] {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(key), databaseRequest.Annotations[key], "annotation is immutable"))
+		}
+	}
+
+	return invalid(databaseRequest, allErrs)
+}
+
+func (v *DatabaseRequestValidator) ValidateDelete(context.Context, runtime.Object) error {
+	return nil
+}
+
+// validateDataSource checks that a clone has a single data source and gets a newly provisioned database
+func validateDataSource(databaseRequest *databasev1alpha1.DatabaseRequest, hasSelector bool) field.ErrorList {
+	var allErrs field.ErrorList
+	annotationsPath := field.NewPath("metadata", "annotations")
+	source, hasSource := databaseRequest.Annotations[databaserequest.DataSourceAnnotation]
+	snapshot, hasSnapshot := databaseRequest.Annotations[databaserequest.DataSourceSnapshotAnnotation]
+	if !hasSource && !hasSnapshot {
+		return nil
+	}
+
+	path := annotationsPath.Key(databaserequest.DataSourceAnnotation)
+	value := source
+	if !hasSource {
+		path = annotationsPath.Key(databaserequest.DataSourceSnapshotAnnotation)
+		value = snapshot
+	}
+	switch {
+	case hasSource && hasSnapshot:
+		allErrs = append(allErrs, field.Forbidden(path,
+			fmt.Sprintf("may not be combined with the %s annotation", databaserequest.DataSourceSnapshotAnnotation)))
+	case value == "":
+		allErrs = append(allErrs, field.Required(path, "must not be empty"))
+	case hasSource && source == databaseRequest.Name:
+		allErrs = append(allErrs, field.Invalid(path, source, "a DatabaseRequest can't be cloned from itself"))
+	}
+	if databaseRequest.Spec.ExistingDatabaseName != "" {
+		allErrs = append(allErrs, field.Forbidden(path, "a data source can't be used with existingBucketName"))
+	}
+	if hasSelector {
+		allErrs = append(allErrs, field.Forbidden(path,
+			fmt.Sprintf("a data source can't be used with the %s annotation", databaserequest.SelectorAnnotation)))
+	}
+	return allErrs
+}
+
+// classExists returns a field error when the cluster-scoped class with the given name does not exist
+func classExists(ctx context.Context, c client.Client, class client.Object, name string, path *field.Path) *field.Error {
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.NotFound(path, name)
+		}
+		return field.InternalError(path, err)
+	}
+	return nil
+}
+
+// immutableOnceSet allows a field to be set once, e.g. by the controller, but not to be changed afterwards
+func immutableOnceSet(newValue, oldValue string, path *field.Path) field.ErrorList {
+	if oldValue != "" && newValue != oldValue {
+		return field.ErrorList{field.Invalid(path, newValue, "field is immutable")}
+	}
+	return nil
+}
+
+func invalid(obj client.Object, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	gvk := databasev1alpha1.GroupVersion.WithKind(kindOf(obj))
+	return apierrors.NewInvalid(gvk.GroupKind(), obj.GetName(), allErrs)
+}
+
+func kindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *databasev1alpha1.DatabaseRequest:
+		return "DatabaseRequest"
+	case *databasev1alpha1.DatabaseAccess:
+		return "DatabaseAccess"
+	case *databasev1alpha1.DatabaseClass:
+		return "DatabaseClass"
+	case *databasev1alpha1.DatabaseAccessClass:
+		return "DatabaseAccessClass"
+	default:
+		return obj.GetObjectKind().GroupVersionKind().Kind
+	}
+}
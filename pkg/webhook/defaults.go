@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	"github.com/pluralsh/database-interface-controller/pkg/databaserequest"
+	"github.com/pluralsh/database-interface-controller/pkg/kubernetes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// DatabaseRequestDefaulter sets the default DatabaseClass on DatabaseRequests without a class
+type DatabaseRequestDefaulter struct {
+	client.Client
+}
+
+var _ admission.CustomDefaulter = &DatabaseRequestDefaulter{}
+
+func (d *DatabaseRequestDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	databaseRequest, ok := obj.(*databasev1alpha1.DatabaseRequest)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseRequest but got a %T", obj)
+	}
+	if databaseRequest.Spec.DatabaseClassName != "" || databaseRequest.Spec.ExistingDatabaseName != "" {
+		return nil
+	}
+	// Requests binding by selector match Databases of any class.
+	if _, ok := databaseRequest.Annotations[databaserequest.SelectorAnnotation]; ok {
+		return nil
+	}
+
+	databaseClass, err := kubernetes.GetDefaultDatabaseClass(ctx, d.Client)
+	if err != nil {
+		return err
+	}
+	databaseRequest.Spec.DatabaseClassName = databaseClass.Name
+
+	return nil
+}
+
+// DatabaseAccessDefaulter sets the default DatabaseAccessClass of the database driver on DatabaseAccesses without a class.
+// When the driver can't be determined yet the class is left empty and chosen by the sidecar.
+type DatabaseAccessDefaulter struct {
+	client.Client
+}
+
+var _ admission.CustomDefaulter = &DatabaseAccessDefaulter{}
+
+func (d *DatabaseAccessDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	databaseAccess, ok := obj.(*databasev1alpha1.DatabaseAccess)
+	if !ok {
+		return fmt.Errorf("expected a DatabaseAccess but got a %T", obj)
+	}
+	if databaseAccess.Spec.DatabaseAccessClassName != "" || databaseAccess.Spec.DatabaseRequestName == "" {
+		return nil
+	}
+
+	namespace := databaseAccess.Namespace
+	if namespace == "" {
+		req, err := admission.RequestFromContext(ctx)
+		if err != nil {
+			return err
+		}
+		namespace = req.Namespace
+	}
+
+	driverName, err := d.driverName(ctx, client.ObjectKey{Name: databaseAccess.Spec.DatabaseRequestName, Namespace: namespace})
+	if err != nil || driverName == "" {
+		return err
+	}
+
+	databaseAccessClass, err := kubernetes.GetDefaultDatabaseAccessClass(ctx, d.Client, driverName)
+	if err != nil {
+		return err
+	}
+	databaseAccess.Spec.DatabaseAccessClassName = databaseAccessClass.Name
+
+	return nil
+}
+
+// driverName returns the driver serving the DatabaseRequest, or an empty string if it is not known yet.
+func (d *DatabaseAccessDefaulter) driverName(ctx context.Context, key client.ObjectKey) (string, error) {
+	databaseRequest := &databasev1alpha1.DatabaseRequest{}
+	if err := d.Get(ctx, key, databaseRequest); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+
+	databaseName := databaseRequest.Status.DatabaseName
+	if databaseName == "" {
+		databaseName = databaseRequest.Spec.ExistingDatabaseName
+	}
+	if databaseName != "" {
+		database := &databasev1alpha1.Database{}
+		if err := d.Get(ctx, client.ObjectKey{Name: databaseName}, database); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		return database.Spec.DriverName, nil
+	}
+
+	if databaseRequest.Spec.DatabaseClassName != "" {
+		databaseClass := &databasev1alpha1.DatabaseClass{}
+		if err := d.Get(ctx, client.ObjectKey{Name: databaseRequest.Spec.DatabaseClassName}, databaseClass); err != nil {
+			return "", client.IgnoreNotFound(err)
+		}
+		return databaseClass.DriverName, nil
+	}
+
+	return "", nil
+}
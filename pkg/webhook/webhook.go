@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	databasev1alpha1 "github.com/pluralsh/database-interface-api/apis/database/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWithManager registers the defaulting and validating webhooks of the user-facing and class objects with the Manager.
+func SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&databasev1alpha1.DatabaseRequest{}).
+		WithDefaulter(&DatabaseRequestDefaulter{Client: mgr.GetClient()}).
+		WithValidator(&DatabaseRequestValidator{Client: mgr.GetClient()}).
+		Complete(); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&databasev1alpha1.DatabaseAccess{}).
+		WithDefaulter(&DatabaseAccessDefaulter{Client: mgr.GetClient()}).
+		WithValidator(&DatabaseAccessValidator{Client: mgr.GetClient()}).
+		Complete(); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&databasev1alpha1.DatabaseClass{}).
+		WithValidator(&DatabaseClassValidator{}).
+		Complete(); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&databasev1alpha1.DatabaseAccessClass{}).
+		WithValidator(&DatabaseAccessClassValidator{}).
+		Complete()
+}